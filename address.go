@@ -1,39 +1,204 @@
 package emailverifier
 
 import (
-	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
-var emailRegex = regexp.MustCompile(emailRegexString)
+// Reasons reported by Syntax.Reason when an address is invalid
+const (
+	SyntaxReasonEmpty             = "empty"
+	SyntaxReasonMissingAt         = "missing_at"
+	SyntaxReasonAddressTooLong    = "address_too_long"
+	SyntaxReasonLocalPartEmpty    = "local_part_empty"
+	SyntaxReasonLocalPartTooLong  = "local_part_too_long"
+	SyntaxReasonLeadingDot        = "leading_dot"
+	SyntaxReasonTrailingDot       = "trailing_dot"
+	SyntaxReasonConsecutiveDots   = "consecutive_dots"
+	SyntaxReasonInvalidCharacter  = "invalid_character"
+	SyntaxReasonUnterminatedQuote = "unterminated_quote"
+	SyntaxReasonInvalidEscape     = "invalid_escape"
+	SyntaxReasonInvalidDomain     = "invalid_domain"
+)
+
+const (
+	maxLocalPartLength = 64
+	maxAddressLength   = 254
+	maxLabelLength     = 63
+)
 
 // Syntax stores all information about an email Syntax
 type Syntax struct {
 	Username string `json:"username"`
 	Domain   string `json:"domain"`
 	Valid    bool   `json:"valid"`
+	Reason   string `json:"reason,omitempty"` // why the address is invalid, one of the SyntaxReason constants
 }
 
 // ParseAddress attempts to parse an email address and return it in the form of an Syntax
 func (v *Verifier) ParseAddress(email string) Syntax {
-
-	isAddressValid := IsAddressValid(email)
-	if !isAddressValid {
-		return Syntax{Valid: false}
+	username, domain, reason := parseAddrSpec(email)
+	if reason != "" {
+		return Syntax{Valid: false, Reason: reason}
 	}
 
-	index := strings.LastIndex(email, "@")
-	username := email[:index]
-	domain := strings.ToLower(email[index+1:])
-
 	return Syntax{
 		Username: username,
-		Domain:   domain,
-		Valid:    isAddressValid,
+		Domain:   strings.ToLower(domain),
+		Valid:    true,
 	}
 }
 
-// IsAddressValid checks if email address is formatted correctly by using regex
+// NormalizeEmail cleans up an email address as found in imported data: surrounding
+// whitespace is trimmed, the address is taken out of a "Name <a@b.com>" form and
+// the domain is lowercased. The local part keeps its case, as it may be case-sensitive.
+// The result isn't validated, invalid characters are left for ParseAddress to report.
+func NormalizeEmail(email string) string {
+	email = strings.TrimSpace(email)
+	if strings.HasSuffix(email, ">") {
+		if i := strings.LastIndex(email, "<"); i >= 0 {
+			email = strings.TrimSpace(email[i+1 : len(email)-1])
+		}
+	}
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		email = email[:i+1] + strings.ToLower(email[i+1:])
+	}
+	return email
+}
+
+// IsAddressValid checks if email address is a valid RFC 5322 addr-spec
 func IsAddressValid(email string) bool {
-	return emailRegex.MatchString(email)
+	_, _, reason := parseAddrSpec(email)
+	return reason == ""
+}
+
+// parseAddrSpec splits an RFC 5322 addr-spec into its local part and domain,
+// the reason is empty when the address is valid.
+// The local part is either a dot-atom or a quoted string, UTF-8 characters are
+// allowed as in RFC 6531. Lengths are counted in octets.
+func parseAddrSpec(email string) (local, domain, reason string) {
+	if email == "" {
+		return "", "", SyntaxReasonEmpty
+	}
+	index := strings.LastIndex(email, "@")
+	if index < 0 {
+		return "", "", SyntaxReasonMissingAt
+	}
+	local, domain = email[:index], email[index+1:]
+	if len(email) > maxAddressLength {
+		return "", "", SyntaxReasonAddressTooLong
+	}
+	if local == "" {
+		return "", "", SyntaxReasonLocalPartEmpty
+	}
+	if len(local) > maxLocalPartLength {
+		return "", "", SyntaxReasonLocalPartTooLong
+	}
+
+	if local[0] == '"' {
+		reason = checkQuotedString(local)
+	} else {
+		reason = checkDotAtom(local)
+	}
+	if reason != "" {
+		return "", "", reason
+	}
+	if !isValidDomain(domain) {
+		return "", "", SyntaxReasonInvalidDomain
+	}
+	return local, domain, ""
+}
+
+// checkDotAtom validates an unquoted local part
+func checkDotAtom(local string) string {
+	if local[0] == '.' {
+		return SyntaxReasonLeadingDot
+	}
+	if local[len(local)-1] == '.' {
+		return SyntaxReasonTrailingDot
+	}
+	if strings.Contains(local, "..") {
+		return SyntaxReasonConsecutiveDots
+	}
+	for _, r := range local {
+		if r != '.' && !isAtext(r) {
+			return SyntaxReasonInvalidCharacter
+		}
+	}
+	return ""
+}
+
+// checkQuotedString validates a local part enclosed in double quotes
+func checkQuotedString(local string) string {
+	if len(local) < 2 || local[len(local)-1] != '"' {
+		return SyntaxReasonUnterminatedQuote
+	}
+	content := local[1 : len(local)-1]
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		switch {
+		case r == '\\':
+			// quoted-pair: a backslash followed by a visible character or whitespace
+			if i+size >= len(content) {
+				return SyntaxReasonUnterminatedQuote
+			}
+			next, nextSize := utf8.DecodeRuneInString(content[i+size:])
+			if next != ' ' && next != '\t' && !isVchar(next) {
+				return SyntaxReasonInvalidEscape
+			}
+			size += nextSize
+		case r == '"':
+			return SyntaxReasonInvalidCharacter
+		case r != ' ' && r != '\t' && !isVchar(r):
+			return SyntaxReasonInvalidCharacter
+		}
+		i += size
+	}
+	return ""
+}
+
+// isValidDomain checks the domain is a host name with at least two labels,
+// an optional trailing dot and an alphabetic top-level label
+func isValidDomain(domain string) bool {
+	domain = strings.TrimSuffix(domain, ".")
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for i, label := range labels {
+		if label == "" || len(label) > maxLabelLength {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if r != '-' && !isLetterOrDigit(r) && !isUTF8Char(r) {
+				return false
+			}
+		}
+		if i == len(labels)-1 && strings.IndexFunc(label, func(r rune) bool { return r < '0' || r > '9' }) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetterOrDigit(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// isAtext reports whether r may appear in an unquoted local part
+func isAtext(r rune) bool {
+	return isLetterOrDigit(r) || strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r) || isUTF8Char(r)
+}
+
+// isVchar reports whether r is a visible character
+func isVchar(r rune) bool {
+	return r >= 0x21 && r <= 0x7e || isUTF8Char(r)
+}
+
+// isUTF8Char reports whether r is an allowed non-ASCII character
+func isUTF8Char(r rune) bool {
+	return r >= 0x00A0 && r <= 0xD7FF || r >= 0xF900 && r <= 0xFDCF || r >= 0xFDF0 && r <= 0xFFEF
 }
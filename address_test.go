@@ -1,7 +1,10 @@
 package emailverifier
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -31,3 +34,54 @@ func TestCheckAddressSyntax(t *testing.T) {
 		}
 	}
 }
+
+func TestParseAddress_RFC5322(t *testing.T) {
+	cases := []struct {
+		mail     string
+		username string
+		reason   string
+	}{
+		{mail: `"john doe"@example.com`, username: `"john doe"`},
+		{mail: `"john\"doe"@example.com`, username: `"john\"doe"`},
+		{mail: `"a@b"@example.com`, username: `"a@b"`},
+		{mail: "!#$%&'*+-/=?^_`{|}~@example.com", username: "!#$%&'*+-/=?^_`{|}~"},
+		{mail: "first.last@example.com.", username: "first.last"},
+		{mail: "", reason: SyntaxReasonEmpty},
+		{mail: "example.com", reason: SyntaxReasonMissingAt},
+		{mail: "@example.com", reason: SyntaxReasonLocalPartEmpty},
+		{mail: ".john@example.com", reason: SyntaxReasonLeadingDot},
+		{mail: "john.@example.com", reason: SyntaxReasonTrailingDot},
+		{mail: "john..doe@example.com", reason: SyntaxReasonConsecutiveDots},
+		{mail: "john doe@example.com", reason: SyntaxReasonInvalidCharacter},
+		{mail: `"john@example.com`, reason: SyntaxReasonUnterminatedQuote},
+		{mail: `"john"doe"@example.com`, reason: SyntaxReasonInvalidCharacter},
+		{mail: "\"john\\\x01\"@example.com", reason: SyntaxReasonInvalidEscape},
+		{mail: strings.Repeat("a", 65) + "@example.com", reason: SyntaxReasonLocalPartTooLong},
+		{mail: strings.Repeat("a", 64) + "@" + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 63) + ".com", reason: SyntaxReasonAddressTooLong},
+		{mail: "john@localhost", reason: SyntaxReasonInvalidDomain},
+		{mail: "john@-example.com", reason: SyntaxReasonInvalidDomain},
+		{mail: "john@example..com", reason: SyntaxReasonInvalidDomain},
+		{mail: "john@1.2.3.4", reason: SyntaxReasonInvalidDomain},
+	}
+	for _, c := range cases {
+		syntax := verifier.ParseAddress(c.mail)
+		assert.Equal(t, c.reason == "", syntax.Valid, c.mail)
+		assert.Equal(t, c.reason, syntax.Reason, c.mail)
+		assert.Equal(t, c.username, syntax.Username, c.mail)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	cases := []struct {
+		mail, expected string
+	}{
+		{mail: "  John.Doe@Example.COM \t", expected: "John.Doe@example.com"},
+		{mail: "John Doe <John.Doe@Example.com>", expected: "John.Doe@example.com"},
+		{mail: `"Doe, John" < john@example.com >`, expected: "john@example.com"},
+		{mail: "john@example.com", expected: "john@example.com"},
+		{mail: "not an email", expected: "not an email"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, NormalizeEmail(c.mail), c.mail)
+	}
+}
@@ -0,0 +1,161 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// VerifyMany verifies emails with at most concurrency concurrent Verify calls
+// (a concurrency below 1 is treated as 1). The results keep the order of emails.
+//
+// Emails are processed grouped by domain and the MX lookup of a domain is shared
+// by all its emails, including their SMTP checks. The error of a single email is attached to its Result.Error
+// rather than aborting the batch. When ctx is done, VerifyMany returns promptly
+// with the results verified so far (nil for the others) and ctx.Err().
+func (v *Verifier) VerifyMany(ctx context.Context, emails []string, concurrency int) ([]*Result, error) {
+	v = v.snapshot()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*Result, len(emails))
+	cache := newMXCache()
+
+	// process emails of the same domain next to each other
+	order := make([]int, len(emails))
+	domains := make([]string, len(emails))
+	for i, email := range emails {
+		order[i] = i
+		domains[i] = v.ParseAddress(email).Domain
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return domains[order[a]] < domains[order[b]]
+	})
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for range concurrency {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ret, err := v.verify(ctx, emails[i], cache)
+				if err != nil {
+					ret.Error = err.Error()
+				}
+				results[i] = ret
+			}
+		}()
+	}
+
+feed:
+	for _, i := range order {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+// VerifyUnique verifies each distinct mailbox of emails once, see VerifyUniqueContext
+func (v *Verifier) VerifyUnique(emails []string) (map[string]*Result, error) {
+	return v.VerifyUniqueContext(context.Background(), emails, 1)
+}
+
+// VerifyUniqueContext groups emails by their canonical form (see Canonicalize), verifies
+// each canonical address once with VerifyMany and maps every email of emails to the Result
+// of its canonical address, e.g. "john+a@gmail.com" and "J.ohn+b@gmail.com" share the single
+// Result of "john@gmail.com". The emails sharing a canonical address share the same *Result,
+// whose Email is the canonical address. When ctx is done, the emails whose canonical
+// address wasn't verified are missing from the map and ctx.Err() is returned.
+func (v *Verifier) VerifyUniqueContext(ctx context.Context, emails []string, concurrency int) (map[string]*Result, error) {
+	v = v.snapshot()
+
+	canonicals := make([]string, len(emails))
+	indexes := map[string]int{}
+	var unique []string
+	for i, email := range emails {
+		canonicals[i] = v.CanonicalizeEmail(email)
+		if _, ok := indexes[canonicals[i]]; !ok {
+			indexes[canonicals[i]] = len(unique)
+			unique = append(unique, canonicals[i])
+		}
+	}
+
+	results, err := v.VerifyMany(ctx, unique, concurrency)
+	grouped := make(map[string]*Result, len(emails))
+	for i, email := range emails {
+		if ret := results[indexes[canonicals[i]]]; ret != nil {
+			grouped[email] = ret
+		}
+	}
+	return grouped, err
+}
+
+// mxCache shares the MX lookup of a domain between concurrent verifications,
+// each domain is only looked up once unless the lookup is cut short by the
+// context of its verification
+type mxCache struct {
+	mu      sync.Mutex
+	entries map[string]*mxCacheEntry
+}
+
+// mxCacheEntry is the MX lookup result of a single domain
+type mxCacheEntry struct {
+	mu   sync.Mutex
+	done bool // the lookup completed, mx and err are its result
+	mx   *Mx
+	err  error
+}
+
+// newMXCache creates an empty mxCache
+func newMXCache() *mxCache {
+	return &mxCache{entries: map[string]*mxCacheEntry{}}
+}
+
+// isContextError reports whether err is caused by ctx being done rather than by the lookup
+func isContextError(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// checkMX returns the cached MX lookup of domain, looking it up with v on a miss.
+// The concurrent lookups of a domain wait for the first one. A lookup failing as
+// ctx is done isn't cached, so the verifications of the other emails of the domain
+// look it up again under their own context. A nil cache always looks up the domain.
+func (c *mxCache) checkMX(ctx context.Context, v *Verifier, domain string) (*Mx, error) {
+	if c == nil {
+		return v.checkMX(ctx, domain)
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[domain]
+	if !ok {
+		entry = &mxCacheEntry{}
+		c.entries[domain] = entry
+	}
+	c.mu.Unlock()
+	if ok {
+		v.logger.Debug("MX cache hit", "domain", domain)
+	} else {
+		v.logger.Debug("MX cache miss", "domain", domain)
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.done {
+		return entry.mx, entry.err
+	}
+	mx, err := v.checkMX(ctx, domain)
+	if err != nil && isContextError(ctx, err) {
+		return mx, err
+	}
+	entry.done, entry.mx, entry.err = true, mx, err
+	return mx, err
+}
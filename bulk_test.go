@@ -0,0 +1,162 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyMany_KeepsOrder(t *testing.T) {
+	v := NewVerifier().AddDisposableDomains("bulk-disposable.test")
+	emails := []string{
+		"b@bulk-disposable.test",
+		"invalid",
+		"a@bulk-disposable.test",
+		"@bulk-disposable.test",
+	}
+
+	results, err := v.VerifyMany(context.Background(), emails, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, results, len(emails)) {
+		for i, email := range emails {
+			assert.Equal(t, email, results[i].Email)
+		}
+		assert.True(t, results[0].Disposable)
+		assert.False(t, results[1].Syntax.Valid)
+		assert.True(t, results[2].Disposable)
+		assert.False(t, results[3].Syntax.Valid)
+	}
+}
+
+func TestVerifyMany_AttachesErrors(t *testing.T) {
+	results, err := NewVerifier().VerifyMany(context.Background(), []string{"email_username@domainnotexists.com"}, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.NotEmpty(t, results[0].Error)
+	}
+}
+
+func TestVerifyMany_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := NewVerifier().VerifyMany(ctx, []string{"a@example.com", "b@example.com"}, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, results, 2)
+}
+
+func TestVerifyUnique_SharesCanonicalResults(t *testing.T) {
+	respond, commands := recordCommands(func(string) string { return "" })
+	defer useFakeSMTPServer(t, respond)()
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	v := NewVerifier().EnableSMTPCheck().DisableCatchAllCheck()
+	emails := []string{"john+a@gmail.com", "J.ohn+b@gmail.com", "john@googlemail.com", "jane@gmail.com", "john+a@gmail.com"}
+	results, err := v.VerifyUnique(emails)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.Equal(t, "john@gmail.com", results["john+a@gmail.com"].Email)
+		assert.Same(t, results["john+a@gmail.com"], results["J.ohn+b@gmail.com"])
+		assert.Same(t, results["john+a@gmail.com"], results["john@googlemail.com"])
+		assert.Equal(t, "jane@gmail.com", results["jane@gmail.com"].Email)
+		assert.True(t, results["jane@gmail.com"].SMTP.Deliverable)
+	}
+	assert.Equal(t, 2, countCommands(commands(), "RCPT"))
+}
+
+func TestVerifyMany_SMTPCheckUsesCachedMX(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	var lookups int32
+	originalLookupMX := lookupMXContext
+	originalSMTPLookupMX := lookupMX
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupMX = originalSMTPLookupMX
+	}()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		atomic.AddInt32(&lookups, 1)
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	lookupMX = func(domain string) ([]*net.MX, error) {
+		atomic.AddInt32(&lookups, 1)
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	v := NewVerifier().EnableSMTPCheck()
+	results, err := v.VerifyMany(context.Background(), []string{"user@example.com", "user@example.com", "other@example.com"}, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.True(t, results[0].SMTP.Deliverable)
+		assert.False(t, results[2].SMTP.Deliverable)
+	}
+	// the SMTP checks dial the MX hosts found by the mx check
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+}
+
+func TestVerifyMany_TimedOutMXLookupIsRetried(t *testing.T) {
+	var lookups int32
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		if atomic.AddInt32(&lookups, 1) == 1 {
+			// the first lookup outlives the total timeout of its email
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	v := NewVerifier().TotalTimeout(100 * time.Millisecond)
+	results, err := v.VerifyMany(context.Background(), []string{"first@example.com", "second@example.com"}, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, DegradedVerifyTimeout, results[0].DegradedReason)
+		assert.Equal(t, DegradedSMTPDisabled, results[1].DegradedReason)
+		assert.True(t, results[1].HasMxRecords)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+}
+
+func TestVerifyUnique_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := NewVerifier().VerifyUniqueContext(ctx, []string{"a@example.com", "b@example.com"}, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, len(results), 2)
+}
+
+func TestMXCache_LooksUpOnce(t *testing.T) {
+	var lookups int32
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		atomic.AddInt32(&lookups, 1)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	cache := newMXCache()
+	v := NewVerifier()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cache.checkMX(ctx, v, "example.com")
+	assert.ErrorIs(t, err, context.Canceled)
+	// the error of a canceled lookup isn't cached, the next caller looks the domain up again
+	mx, err := cache.checkMX(context.Background(), v, "example.com")
+	assert.NoError(t, err)
+	assert.True(t, mx.HasMXRecord)
+	_, err = cache.checkMX(context.Background(), v, "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+}
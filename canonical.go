@@ -0,0 +1,155 @@
+package emailverifier
+
+import (
+	"maps"
+	"strings"
+)
+
+// Canonicalization rules reported by Canonicalize
+const (
+	CanonicalRuleStripPlus      = "strip_plus"      // the sub-address after '+' was removed
+	CanonicalRuleStripDots      = "strip_dots"      // dots in the local part were removed
+	CanonicalRuleLowercaseLocal = "lowercase_local" // the local part was lowercased
+	CanonicalRuleFoldDomain     = "fold_domain"     // the domain was an alias folded into its primary domain
+)
+
+// DefaultDomainAliases maps alias domains to the primary domain sharing their mailbox namespace,
+// i.e. "john@googlemail.com" and "john@gmail.com" are the same mailbox. It may be extended before
+// the verifier is used, use MapDomainAlias for mappings of a single verifier.
+var DefaultDomainAliases = map[string]string{
+	"googlemail.com": "gmail.com",
+	"me.com":         "icloud.com",
+	"mac.com":        "icloud.com",
+	"ya.ru":          "yandex.ru",
+	"yandex.com":     "yandex.ru",
+	"yandex.by":      "yandex.ru",
+	"yandex.kz":      "yandex.ru",
+	"yandex.ua":      "yandex.ru",
+}
+
+// canonicalProvider describes how a mail provider treats the local part
+type canonicalProvider struct {
+	ignoreDots      bool // provider delivers "j.ohn" and "john" to the same mailbox
+	caseInsensitive bool // provider treats the local part case-insensitively
+	plusAddressing  bool // provider delivers "john+tag" to the mailbox "john"
+}
+
+// canonicalProviders are providers with known local part rules,
+// unknown providers only have their plus-addressing removed
+var canonicalProviders = map[string]canonicalProvider{
+	"gmail.com":      {ignoreDots: true, caseInsensitive: true, plusAddressing: true},
+	"googlemail.com": {ignoreDots: true, caseInsensitive: true, plusAddressing: true},
+	"outlook.com":    {caseInsensitive: true, plusAddressing: true},
+	"hotmail.com":    {caseInsensitive: true, plusAddressing: true},
+	"live.com":       {caseInsensitive: true, plusAddressing: true},
+	"msn.com":        {caseInsensitive: true, plusAddressing: true},
+	"icloud.com":     {caseInsensitive: true, plusAddressing: true},
+	"me.com":         {caseInsensitive: true, plusAddressing: true},
+	"fastmail.com":   {caseInsensitive: true, plusAddressing: true},
+	"protonmail.com": {caseInsensitive: true, plusAddressing: true},
+	"proton.me":      {caseInsensitive: true, plusAddressing: true},
+}
+
+// Canonical is the canonical form of an email address
+type Canonical struct {
+	Email string   `json:"email"` // canonical email address
+	Rules []string `json:"rules"` // rules applied to get the canonical address
+}
+
+// CanonicalizeEmail returns the canonical form of the email address,
+// which can be used for deduplication and fraud detection
+func (v *Verifier) CanonicalizeEmail(email string) string {
+	return v.Canonicalize(email).Email
+}
+
+// Canonicalize returns the canonical form of the email address together
+// with the rules applied. The domain is always lowercased, alias domains are
+// folded into their primary domain (see NormalizeDomain) and the sub-address
+// after the first '+' is removed. Dots are only removed from the local part
+// for providers that ignore them (e.g. gmail.com).
+func (v *Verifier) Canonicalize(email string) Canonical {
+	v = v.snapshot()
+	index := strings.LastIndex(email, "@")
+	if index <= 0 || index == len(email)-1 {
+		return Canonical{Email: email}
+	}
+
+	username := email[:index]
+	domain := strings.ToLower(email[index+1:])
+
+	var rules []string
+	if primary := v.primaryDomain(domain); primary != domain {
+		domain = primary
+		rules = append(rules, CanonicalRuleFoldDomain)
+	}
+	provider := canonicalProviders[domain]
+
+	if stripped := stripPlusAddressing(username); stripped != username {
+		username = stripped
+		rules = append(rules, CanonicalRuleStripPlus)
+	}
+	if provider.ignoreDots && strings.Contains(username, ".") {
+		username = strings.ReplaceAll(username, ".", "")
+		rules = append(rules, CanonicalRuleStripDots)
+	}
+	if provider.caseInsensitive && strings.ToLower(username) != username {
+		username = strings.ToLower(username)
+		rules = append(rules, CanonicalRuleLowercaseLocal)
+	}
+
+	return Canonical{
+		Email: username + "@" + domain,
+		Rules: rules,
+	}
+}
+
+// NormalizeDomain returns the primary domain of domain, which is lowercased and
+// stripped of its trailing dot. Alias domains sharing the mailbox namespace of
+// a primary domain are folded into it, e.g. "GoogleMail.com" becomes "gmail.com".
+// The aliases are the ones of MapDomainAlias, then DefaultDomainAliases.
+func (v *Verifier) NormalizeDomain(domain string) string {
+	v = v.snapshot()
+	return v.primaryDomain(cleanDomain(domain))
+}
+
+// MapDomainAlias folds the alias domain into the primary domain in NormalizeDomain,
+// Canonicalize and Result.CanonicalDomain, e.g. the vanity domain of a company into
+// the domain of its mailboxes. It takes precedence over DefaultDomainAliases, mapping
+// an alias to itself removes a default alias.
+func (v *Verifier) MapDomainAlias(alias, primary string) *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// the map is copied as snapshots of running checks share it
+	v.domainAliases = maps.Clone(v.domainAliases)
+	if v.domainAliases == nil {
+		v.domainAliases = map[string]string{}
+	}
+	v.domainAliases[cleanDomain(alias)] = cleanDomain(primary)
+	return v
+}
+
+// primaryDomain returns the primary domain of the lowercased domain, domain itself when it isn't an alias
+func (v *Verifier) primaryDomain(domain string) string {
+	return primaryDomainOf(v.domainAliases, domain)
+}
+
+// primaryDomainOf returns the primary domain of domain among aliases and the DefaultDomainAliases
+func primaryDomainOf(aliases map[string]string, domain string) string {
+	if primary, ok := aliases[domain]; ok {
+		return primary
+	}
+	if primary, ok := DefaultDomainAliases[domain]; ok {
+		return primary
+	}
+	return domain
+}
+
+// mailboxUsername returns the username of the base mailbox when plus address
+// normalization is enabled and the provider of domain supports plus-addressing,
+// otherwise the username is returned as is
+func (v *Verifier) mailboxUsername(username, domain string) string {
+	if !v.plusAddressNormalization || !canonicalProviders[strings.ToLower(domain)].plusAddressing {
+		return username
+	}
+	return stripPlusAddressing(username)
+}
@@ -0,0 +1,57 @@
+package emailverifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalizeEmail_Gmail(t *testing.T) {
+	ret := verifier.Canonicalize("John.Doe+newsletter@GMail.com")
+	assert.Equal(t, "johndoe@gmail.com", ret.Email)
+	assert.Equal(t, []string{CanonicalRuleStripPlus, CanonicalRuleStripDots, CanonicalRuleLowercaseLocal}, ret.Rules)
+}
+
+func TestCanonicalizeEmail_OutlookKeepsDots(t *testing.T) {
+	ret := verifier.Canonicalize("john.doe+tag@outlook.com")
+	assert.Equal(t, "john.doe@outlook.com", ret.Email)
+	assert.Equal(t, []string{CanonicalRuleStripPlus}, ret.Rules)
+}
+
+func TestCanonicalizeEmail_UnknownProvider(t *testing.T) {
+	assert.Equal(t, "John.Doe@example.com", verifier.CanonicalizeEmail("John.Doe+tag@Example.com"))
+	assert.Equal(t, "John.Doe@example.com", verifier.CanonicalizeEmail("John.Doe@example.com"))
+}
+
+func TestCanonicalizeEmail_Invalid(t *testing.T) {
+	assert.Equal(t, "not-an-email", verifier.CanonicalizeEmail("not-an-email"))
+	assert.Equal(t, "@example.com", verifier.CanonicalizeEmail("@example.com"))
+
+	ret := verifier.Canonicalize("john@")
+	assert.Equal(t, "john@", ret.Email)
+	assert.Nil(t, ret.Rules)
+}
+
+func TestCanonicalizeEmail_DomainAlias(t *testing.T) {
+	ret := verifier.Canonicalize("John.Doe@GoogleMail.com")
+	assert.Equal(t, "johndoe@gmail.com", ret.Email)
+	assert.Equal(t, []string{CanonicalRuleFoldDomain, CanonicalRuleStripDots, CanonicalRuleLowercaseLocal}, ret.Rules)
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	assert.Equal(t, "gmail.com", verifier.NormalizeDomain(" GoogleMail.com. "))
+	assert.Equal(t, "icloud.com", verifier.NormalizeDomain("me.com"))
+	assert.Equal(t, "example.com", verifier.NormalizeDomain("Example.com"))
+}
+
+func TestMapDomainAlias(t *testing.T) {
+	v := NewVerifier().
+		MapDomainAlias("Mail.Example.com", "example.com").
+		MapDomainAlias("googlemail.com", "googlemail.com")
+	assert.Equal(t, "example.com", v.NormalizeDomain("mail.example.com"))
+	assert.Equal(t, "googlemail.com", v.NormalizeDomain("googlemail.com"))
+	assert.Equal(t, "john@example.com", v.CanonicalizeEmail("john+tag@mail.example.com"))
+
+	// other verifiers keep the default aliases
+	assert.Equal(t, "gmail.com", verifier.NormalizeDomain("googlemail.com"))
+}
@@ -0,0 +1,132 @@
+package emailverifier
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// catchAllShareTTL is how long the catch-all status of an MX host is shared, see ShareCatchAllByMX
+const catchAllShareTTL = time.Hour
+
+// catchAllShareMaxHosts is the number of MX hosts whose catch-all status is kept,
+// the expired statuses are swept and then the oldest ones evicted beyond it
+const catchAllShareMaxHosts = 10000
+
+// catchAllByMX shares the catch-all status probed on a domain with the other domains
+// of the same dedicated MX host, e.g. the vanity domains of a self-hosted mail server.
+// All methods are no-ops on a nil catchAllByMX.
+type catchAllByMX struct {
+	mu    sync.Mutex
+	hosts map[string]sharedCatchAll // statuses keyed by the lowercased MX host
+}
+
+// sharedCatchAll is the catch-all status probed on domain
+type sharedCatchAll struct {
+	status  CatchAllStatus
+	domain  string
+	expires time.Time
+}
+
+// newCatchAllByMX creates an empty catchAllByMX
+func newCatchAllByMX() *catchAllByMX {
+	return &catchAllByMX{hosts: map[string]sharedCatchAll{}}
+}
+
+// get returns the unexpired catch-all status of host and the domain it was probed on
+func (c *catchAllByMX) get(host string) (sharedCatchAll, bool) {
+	if c == nil {
+		return sharedCatchAll{}, false
+	}
+	key := strings.ToLower(strings.TrimSuffix(host, "."))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shared, ok := c.hosts[key]
+	if ok && !time.Now().Before(shared.expires) {
+		delete(c.hosts, key)
+		return sharedCatchAll{}, false
+	}
+	return shared, ok
+}
+
+// put records the catch-all status of host probed on domain, an unknown status isn't shared
+func (c *catchAllByMX) put(host, domain string, status CatchAllStatus) {
+	if c == nil || status == CatchAllUnknown {
+		return
+	}
+	key := strings.ToLower(strings.TrimSuffix(host, "."))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.hosts[key]; !ok && len(c.hosts) >= catchAllShareMaxHosts {
+		c.sweep(now)
+	}
+	c.hosts[key] = sharedCatchAll{status: status, domain: domain, expires: now.Add(catchAllShareTTL)}
+}
+
+// sweep deletes the statuses expired at now, then the oldest ones until there is room
+// for another host. c.mu must be held.
+func (c *catchAllByMX) sweep(now time.Time) {
+	for key, shared := range c.hosts {
+		if !now.Before(shared.expires) {
+			delete(c.hosts, key)
+		}
+	}
+	for len(c.hosts) >= catchAllShareMaxHosts {
+		var oldest string
+		for key, shared := range c.hosts {
+			if oldest == "" || shared.expires.Before(c.hosts[oldest].expires) {
+				oldest = key
+			}
+		}
+		delete(c.hosts, oldest)
+	}
+}
+
+// ShareCatchAllByMX reuses the catch-all status probed on a domain for the other domains
+// of the same MX host during an hour, instead of probing each of them, e.g. the vanity
+// domains of a company pointing to its own mail server. The MX host stands for the tenant,
+// so the gateways of the mail providers of DefaultMailProviderMX, which serve unrelated
+// customers (every Google Workspace domain shares aspmx.l.google.com), are always probed;
+// add a multi-tenant hosting service missing from it to keep its customers apart.
+// SMTP.CatchAllSharedFrom tells the domain the status was probed on. It is disabled by
+// default as the domains of a host may have their own policies, e.g. a catch-all enabled
+// on a single domain of the host would be reported for all of them.
+func (v *Verifier) ShareCatchAllByMX() *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.catchAllShared == nil {
+		v.catchAllShared = newCatchAllByMX()
+	}
+	return v
+}
+
+// UnshareCatchAllByMX probes the catch-all status of every domain again, see ShareCatchAllByMX
+func (v *Verifier) UnshareCatchAllByMX() *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.catchAllShared = nil
+	return v
+}
+
+// probeCatchAllOfHost is probeCatchAll reusing the catch-all status of the other domains
+// of host when ShareCatchAllByMX is enabled, a probed status is shared with them. The
+// hosts of the mail providers are shared by unrelated tenants, their domains are probed.
+func (v *Verifier) probeCatchAllOfHost(client *smtp.Client, host, domain string, tr *transcript, pacer *rcptPacer, ret *SMTP) error {
+	if mailProviderOf(host) != "" {
+		return v.probeCatchAll(client, domain, tr, pacer, ret)
+	}
+	if shared, ok := v.catchAllShared.get(host); ok {
+		ret.CatchAllStatus = shared.status
+		ret.CatchAll = shared.status == CatchAllYes
+		ret.CatchAllSharedFrom = shared.domain
+		tr.note(fmt.Sprintf("catch-all status %s shared by %s on the same MX host", shared.status, shared.domain))
+		v.logger.Debug("catch-all status shared by the MX host", "host", host, "domain", domain, "from", shared.domain)
+		return nil
+	}
+	err := v.probeCatchAll(client, domain, tr, pacer, ret)
+	v.catchAllShared.put(host, domain, ret.CatchAllStatus)
+	return err
+}
@@ -0,0 +1,109 @@
+package emailverifier
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareCatchAllByMX(t *testing.T) {
+	respond, commands := recordCommands(rejectRandomRcpt)
+	defer useFakeSMTPServer(t, respond)()
+
+	const mx = "mail.tenant.example"
+	v := NewVerifier().EnableSMTPCheck().ShareCatchAllByMX()
+	ret, err := v.CheckSMTPWithMX(mx, "tenant.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+	assert.Empty(t, ret.CatchAllSharedFrom)
+	assert.Equal(t, 2, countCommands(commands(), "RCPT"))
+
+	// a sibling domain of the same MX host isn't probed
+	ret, err = v.CheckSMTPWithMX(mx+".", "tenant-vanity.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+	assert.Equal(t, "tenant.com", ret.CatchAllSharedFrom)
+	assert.True(t, ret.Deliverable)
+	assert.Equal(t, 3, countCommands(commands(), "RCPT"))
+
+	// other MX hosts are probed
+	ret, err = v.CheckSMTPWithMX("mx.other.example", "other.com", "user")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.CatchAllSharedFrom)
+	assert.Equal(t, 5, countCommands(commands(), "RCPT"))
+
+	ret, err = v.UnshareCatchAllByMX().CheckSMTPWithMX(mx, "tenant-vanity.com", "user")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.CatchAllSharedFrom)
+	assert.Equal(t, 7, countCommands(commands(), "RCPT"))
+}
+
+func TestShareCatchAllByMX_ProviderGateway(t *testing.T) {
+	respond, commands := recordCommands(rejectRandomRcpt)
+	defer useFakeSMTPServer(t, respond)()
+
+	// the gateway of a provider serves unrelated customers, each of them is probed
+	v := NewVerifier().EnableSMTPCheck().ShareCatchAllByMX()
+	_, err := v.CheckSMTPWithMX("aspmx.l.google.com", "customer.com", "user")
+	assert.NoError(t, err)
+	ret, err := v.CheckSMTPWithMX("aspmx.l.google.com", "unrelated.com", "user")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.CatchAllSharedFrom)
+	assert.Equal(t, 4, countCommands(commands(), "RCPT"))
+}
+
+func TestShareCatchAllByMX_CatchAll(t *testing.T) {
+	respond, commands := recordCommands(func(cmd string) string { return "" })
+	defer useFakeSMTPServer(t, respond)()
+
+	v := NewVerifier().EnableSMTPCheck().ShareCatchAllByMX()
+	_, err := v.CheckSMTPWithMX("mx.tenant.example", "tenant.com", "")
+	assert.NoError(t, err)
+
+	ret, err := v.CheckSMTPWithMX("mx.tenant.example", "tenant-vanity.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchAllYes, ret.CatchAllStatus)
+	assert.True(t, ret.CatchAll)
+	assert.False(t, ret.Deliverable)
+	assert.Equal(t, "tenant.com", ret.CatchAllSharedFrom)
+	// the target is still sent, to record its rejection
+	assert.Contains(t, commands(), "RCPT TO:<user@tenant-vanity.com>")
+	assert.Equal(t, 2, countCommands(commands(), "RCPT"))
+}
+
+func TestCatchAllByMX_Expires(t *testing.T) {
+	c := newCatchAllByMX()
+	c.put("MX.example.com.", "example.com", CatchAllUnknown)
+	_, ok := c.get("mx.example.com")
+	assert.False(t, ok)
+
+	c.put("MX.example.com.", "example.com", CatchAllYes)
+	shared, ok := c.get("mx.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, CatchAllYes, shared.status)
+
+	c.hosts["mx.example.com"] = sharedCatchAll{status: CatchAllYes, expires: time.Now().Add(-time.Second)}
+	_, ok = c.get("mx.example.com")
+	assert.False(t, ok)
+}
+
+func TestCatchAllByMX_Bounded(t *testing.T) {
+	c := newCatchAllByMX()
+	for i := 0; i < catchAllShareMaxHosts; i++ {
+		c.hosts[fmt.Sprintf("mx%d.example.com", i)] = sharedCatchAll{status: CatchAllNo, expires: time.Now().Add(time.Duration(i) * time.Second)}
+	}
+	c.hosts["mx0.example.com"] = sharedCatchAll{status: CatchAllNo, expires: time.Now().Add(-time.Second)}
+
+	// the expired hosts are swept
+	c.put("mx.new.example", "new.example", CatchAllNo)
+	assert.Len(t, c.hosts, catchAllShareMaxHosts)
+	assert.NotContains(t, c.hosts, "mx0.example.com")
+
+	// then the oldest ones are evicted
+	c.put("mx.other.example", "other.example", CatchAllNo)
+	assert.Len(t, c.hosts, catchAllShareMaxHosts)
+	assert.NotContains(t, c.hosts, "mx1.example.com")
+	assert.Contains(t, c.hosts, "mx.other.example")
+}
@@ -0,0 +1,51 @@
+package emailverifier
+
+// Backends of ChecksPerformed.MailboxBackend, which produced Result.SMTP
+const (
+	MailboxBackendSMTP    = "smtp"            // the SMTP check of the MX hosts
+	MailboxBackendAPI     = "api"             // an API verifier, see EnableAPIVerifier
+	MailboxBackendChecker = "mailbox_checker" // a MailboxChecker, see RegisterMailboxChecker
+	MailboxBackendTrusted = "trusted"         // the verdict of a trusted domain, see TrustDomains
+	MailboxBackendRelay   = "relay"           // the RCPT check of a submission relay, see SubmissionRelay
+)
+
+// ChecksPerformed tells which checks of a verification ran, whether they succeeded or
+// not, so a missing field of the Result reads as a skipped check rather than a failed
+// one. The checks after the syntax check are skipped for an invalid address, and the
+// network checks for a disposable domain. A check which ran and failed is reported by
+// the error of the verification and Result.Degraded.
+type ChecksPerformed struct {
+	SyntaxChecked      bool `json:"syntax_checked"`
+	DisposableChecked  bool `json:"disposable_checked"`
+	RoleAccountChecked bool `json:"role_account_checked"`
+	FreeChecked        bool `json:"free_checked"`       // see EnableFreeCheck
+	SuggestionChecked  bool `json:"suggestion_checked"` // see EnableDomainSuggest
+	MXChecked          bool `json:"mx_checked"`
+	// SMTPChecked is a connection to the MX hosts, it isn't attempted for a domain
+	// without mail (null MX) nor a domain checked by another backend
+	SMTPChecked bool `json:"smtp_checked"`
+	// MailboxChecked is a check of the mailbox by any backend, it is false in MX-only
+	// mode, for a trusted domain and without username, e.g. by CheckDomain
+	MailboxChecked bool `json:"mailbox_checked"`
+	// MailboxBackend is the backend which checked the mailbox, one of the MailboxBackend
+	// constants, empty when SMTP is disabled or the MX check failed
+	MailboxBackend   string `json:"mailbox_backend,omitempty"`
+	CatchAllChecked  bool   `json:"catch_all_checked"` // the catch-all probe was part of the SMTP check, see DisableCatchAllCheck
+	GravatarChecked  bool   `json:"gravatar_checked"`  // see EnableGravatarCheck
+	DomainAgeChecked bool   `json:"domain_age_checked"`
+	SenderChecked    bool   `json:"sender_checked"` // see EnableSenderCheck
+}
+
+// recordMailbox records the checks of the mailbox of username made by backend, empty
+// when no backend ran
+func (c *ChecksPerformed) recordMailbox(v *Verifier, backend, username string) {
+	c.MailboxBackend = backend
+	switch backend {
+	case MailboxBackendSMTP:
+		c.SMTPChecked = true
+		c.MailboxChecked = !v.mxOnlyMode && username != ""
+		c.CatchAllChecked = !v.mxOnlyMode && v.catchAllCheckEnabled
+	case MailboxBackendAPI, MailboxBackendChecker, MailboxBackendRelay:
+		c.MailboxChecked = username != ""
+	}
+}
@@ -0,0 +1,67 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_ChecksPerformed(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	defer useFakeMX()()
+
+	ret, err := NewVerifier().DisableFreeCheck().Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, ChecksPerformed{
+		SyntaxChecked:      true,
+		DisposableChecked:  true,
+		RoleAccountChecked: true,
+		MXChecked:          true,
+	}, ret.ChecksPerformed)
+
+	ret, err = NewVerifier().EnableSMTPCheck().EnableDomainSuggest().Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, ChecksPerformed{
+		SyntaxChecked:      true,
+		DisposableChecked:  true,
+		RoleAccountChecked: true,
+		FreeChecked:        true,
+		SuggestionChecked:  true,
+		MXChecked:          true,
+		SMTPChecked:        true,
+		MailboxChecked:     true,
+		MailboxBackend:     MailboxBackendSMTP,
+		CatchAllChecked:    true,
+	}, ret.ChecksPerformed)
+
+	ret, err = NewVerifier().EnableSMTPCheck().DisableCatchAllCheck().EnableMXOnlyMode().Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ret.ChecksPerformed.SMTPChecked)
+	assert.False(t, ret.ChecksPerformed.MailboxChecked)
+	assert.False(t, ret.ChecksPerformed.CatchAllChecked)
+
+	partner := MailboxCheckerFunc(func(ctx context.Context, domain, username string) (*SMTP, error) {
+		return &SMTP{HostExists: true, Deliverable: true}, nil
+	})
+	ret, err = NewVerifier().EnableSMTPCheck().RegisterMailboxChecker(partner, "example.com").Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.ChecksPerformed.SMTPChecked)
+	assert.True(t, ret.ChecksPerformed.MailboxChecked)
+	assert.False(t, ret.ChecksPerformed.CatchAllChecked)
+	assert.Equal(t, MailboxBackendChecker, ret.ChecksPerformed.MailboxBackend)
+
+	ret, err = NewVerifier().EnableSMTPCheck().TrustDomains("example.com").Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.ChecksPerformed.SMTPChecked)
+	assert.False(t, ret.ChecksPerformed.MailboxChecked)
+	assert.Equal(t, MailboxBackendTrusted, ret.ChecksPerformed.MailboxBackend)
+
+	// the checks which ran are reported along with their failure
+	defer failDials(errors.New("dial tcp 192.0.2.1:25: connect: connection refused"))()
+	ret, err = NewVerifier().EnableSMTPCheck().Verify("user@example.com")
+	assert.Error(t, err)
+	assert.True(t, ret.ChecksPerformed.SMTPChecked)
+	assert.Equal(t, MailboxBackendSMTP, ret.ChecksPerformed.MailboxBackend)
+}
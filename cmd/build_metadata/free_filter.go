@@ -2,20 +2,32 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-const workerCount = 10
-const srcPath = "free.txt"          // file with one domain per line
-const dstPath = "free_valid_mx.txt" // file with one domain per line (only those with valid MX record)
+// filterConfig configures filterFreeDomainsWithValidMX
+type filterConfig struct {
+	workers int           // number of concurrent MX lookups
+	srcPath string        // file with one domain per line
+	dstPath string        // file with one domain per line (only those with valid MX record)
+	timeout time.Duration // deadline of each MX lookup, a domain exceeding it is dropped
+}
 
 // filterFreeDomainsWithValidMX filters out free domains that do not have a valid MX record
-func filterFreeDomainsWithValidMX() {
+func filterFreeDomainsWithValidMX(cfg filterConfig) {
+	srcPath, dstPath := cfg.srcPath, cfg.dstPath
+	workerCount := max(cfg.workers, 1)
+	resolver := &net.Resolver{PreferGo: true}
+	var kept, dropped int64
 
 	// open source file for reading
 	srcFile, err := os.Open(srcPath)
@@ -48,8 +60,11 @@ func filterFreeDomainsWithValidMX() {
 		go func() {
 			defer wg.Done()
 			for domain := range jobs {
-				if hasValidMX(domain) {
+				if hasValidMX(resolver, domain, cfg.timeout) {
+					atomic.AddInt64(&kept, 1)
 					results <- domain
+				} else {
+					atomic.AddInt64(&dropped, 1)
 				}
 			}
 		}()
@@ -61,40 +76,68 @@ func filterFreeDomainsWithValidMX() {
 		close(results)
 	}()
 
-	// writer: consume results and write to destination file
-	var writerWg sync.WaitGroup
-	writerWg.Add(1)
+	// collector: buffer the results, they are written sorted once all lookups are done
+	var valid []string
+	var collectorWg sync.WaitGroup
+	collectorWg.Add(1)
 	go func() {
-		defer writerWg.Done()
+		defer collectorWg.Done()
 		for domain := range results {
-			if _, err := fmt.Fprintln(dstFile, domain); err != nil {
-				log.Printf("failed to write domain %s to %s: %v", domain, dstPath, err)
-			}
+			valid = append(valid, domain)
 		}
 	}()
 
-	// reader: scan source file and send domains to workers
+	// reader: scan source file and send each normalized domain once to workers
+	seen := make(map[string]struct{})
+	var duplicates int
 	scanner := bufio.NewScanner(srcFile)
 	scanner.Split(bufio.ScanLines)
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+		domain := normalizeDomain(scanner.Text())
+		if domain == "" {
+			continue
+		}
+		if _, ok := seen[domain]; ok {
+			duplicates++
 			continue
 		}
-		jobs <- line
+		seen[domain] = struct{}{}
+		jobs <- domain
 	}
 	if err := scanner.Err(); err != nil {
 		log.Printf("error reading from source file %s: %v", srcPath, err)
 	}
 	close(jobs)
 
-	// wait for writer to finish consuming all results
-	writerWg.Wait()
+	// wait for collector to finish consuming all results
+	collectorWg.Wait()
+
+	// sorted output keeps the diffs between regenerations reviewable
+	sort.Strings(valid)
+	writer := bufio.NewWriter(dstFile)
+	for _, domain := range valid {
+		if _, err := fmt.Fprintln(writer, domain); err != nil {
+			log.Printf("failed to write domain %s to %s: %v", domain, dstPath, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		log.Printf("failed to write %s: %v", dstPath, err)
+	}
+
+	fmt.Printf("Filtered %s: kept %d domains, dropped %d domains without a valid MX record, skipped %d duplicates\n",
+		srcPath, kept, dropped, duplicates)
+}
+
+// normalizeDomain lowercases domain and trims the surrounding spaces and the trailing dot
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
 }
 
-// hasValidMX checks if a domain has a valid MX record
-func hasValidMX(domain string) bool {
-	mx, err := net.LookupMX(domain)
+// hasValidMX checks if a domain has a valid MX record, a lookup exceeding timeout counts as invalid
+func hasValidMX(resolver *net.Resolver, domain string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	mx, err := resolver.LookupMX(ctx, domain)
 	if err != nil {
 		return false
 	}
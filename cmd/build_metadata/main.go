@@ -3,12 +3,16 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // writeFile writes content to a file
@@ -31,7 +35,8 @@ type fileInfo struct {
 	description string
 }
 
-func buildMetaDataFile() {
+// buildMetaDataFile generates the metadata go files, free domains are read from freePath
+func buildMetaDataFile(freePath string) {
 	var files []fileInfo
 	files = append(files,
 		fileInfo{
@@ -41,7 +46,7 @@ func buildMetaDataFile() {
 			description: "// map to store disposable domains data",
 		},
 		fileInfo{
-			path:        "free_valid_mx.txt",
+			path:        freePath,
 			varName:     "freeDomains",
 			srcPath:     "../../metadata_free.go",
 			description: "// map to store free domains data",
@@ -73,6 +78,9 @@ func buildMetaDataFile() {
 		data := make(map[string]bool)
 		for scanner.Scan() {
 			key := scanner.Text()
+			if err := checkEntry(key); err != nil {
+				log.Fatalf("invalid entry in %s: %s", f.path, err)
+			}
 
 			if !data[key] {
 				output.WriteString("\t")
@@ -97,6 +105,17 @@ func buildMetaDataFile() {
 
 }
 
+// checkEntry validates a line of a metadata file. Besides plain entries, the disposable
+// domains accept "*.example.com" wildcards and "/regexp/" entries, a regexp which doesn't
+// compile would be skipped at runtime so it fails the build instead.
+func checkEntry(entry string) error {
+	if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+		_, err := regexp.Compile(entry[1 : len(entry)-1])
+		return err
+	}
+	return nil
+}
+
 func updateMetaData() {
 	cmd := exec.Command(
 		"/bin/bash",
@@ -138,7 +157,20 @@ func updateMetaData() {
 }
 
 func main() {
+	var cfg filterConfig
+	flag.IntVar(&cfg.workers, "workers", 10, "number of concurrent MX lookups")
+	flag.StringVar(&cfg.srcPath, "src", "free.txt", "file of free domains, one domain per line")
+	flag.StringVar(&cfg.dstPath, "dst", "free_valid_mx.txt", "file of the free domains with a valid MX record")
+	flag.DurationVar(&cfg.timeout, "timeout", 5*time.Second, "deadline of each MX lookup, slower domains are dropped")
+	flag.Parse()
+	if cfg.workers < 1 {
+		log.Fatalf("workers must be at least 1, got %d", cfg.workers)
+	}
+	if cfg.timeout <= 0 {
+		log.Fatalf("timeout must be positive, got %s", cfg.timeout)
+	}
+
 	updateMetaData()
-	filterFreeDomainsWithValidMX()
-	buildMetaDataFile()
+	filterFreeDomainsWithValidMX(cfg)
+	buildMetaDataFile(cfg.dstPath)
 }
@@ -0,0 +1,90 @@
+package emailverifier
+
+import "strconv"
+
+// csvColumn is a CSV column of a Result, nested structs are flattened
+// with their json name as prefix (e.g. smtp_catch_all)
+type csvColumn struct {
+	name  string
+	value func(r *Result) string
+}
+
+// csvGroup is a group of CSV columns reading the same part of a Result,
+// its columns are empty when present reports that the part is nil
+type csvGroup struct {
+	present func(r *Result) bool
+	columns []csvColumn
+}
+
+// csvGroups are the CSV columns of a Result in order, a column is added to its group
+var csvGroups = []csvGroup{
+	{columns: []csvColumn{
+		{"email", func(r *Result) string { return r.Email }},
+		{"reachable", func(r *Result) string { return r.Reachable }},
+		{"syntax_username", func(r *Result) string { return r.Syntax.Username }},
+		{"syntax_domain", func(r *Result) string { return r.Syntax.Domain }},
+		{"syntax_valid", func(r *Result) string { return strconv.FormatBool(r.Syntax.Valid) }},
+		{"syntax_reason", func(r *Result) string { return r.Syntax.Reason }},
+		{"suggestion", func(r *Result) string { return r.Suggestion }},
+		{"disposable", func(r *Result) string { return strconv.FormatBool(r.Disposable) }},
+		{"disposable_source", func(r *Result) string { return r.DisposableSource }},
+		{"role_account", func(r *Result) string { return strconv.FormatBool(r.RoleAccount) }},
+		{"role_name", func(r *Result) string { return r.RoleName }},
+		{"free", func(r *Result) string { return strconv.FormatBool(r.Free) }},
+		{"parked", func(r *Result) string { return strconv.FormatBool(r.Parked) }},
+		{"verified_email", func(r *Result) string { return r.VerifiedEmail }},
+		{"normalized_email", func(r *Result) string { return r.NormalizedEmail }},
+		{"canonical_domain", func(r *Result) string { return r.CanonicalDomain }},
+		{"degraded", func(r *Result) string { return strconv.FormatBool(r.Degraded) }},
+		{"degraded_reason", func(r *Result) string { return r.DegradedReason }},
+		{"error", func(r *Result) string { return r.Error }},
+	}},
+	{columns: []csvColumn{
+		{"has_mx_records", func(r *Result) string { return strconv.FormatBool(r.HasMxRecords) }},
+		{"used_implicit_mx", func(r *Result) string { return strconv.FormatBool(r.UsedImplicitMX) }},
+		{"null_mx", func(r *Result) string { return strconv.FormatBool(r.NullMX) }},
+	}},
+	{present: func(r *Result) bool { return r.SMTP != nil }, columns: []csvColumn{
+		{"smtp_host_exists", func(r *Result) string { return strconv.FormatBool(r.SMTP.HostExists) }},
+		{"smtp_full_inbox", func(r *Result) string { return strconv.FormatBool(r.SMTP.FullInbox) }},
+		{"smtp_full_inbox_permanent", func(r *Result) string { return strconv.FormatBool(r.SMTP.FullInboxPermanent) }},
+		{"smtp_catch_all", func(r *Result) string { return strconv.FormatBool(r.SMTP.CatchAll) }},
+		{"smtp_catch_all_status", func(r *Result) string { return string(r.SMTP.CatchAllStatus) }},
+		{"smtp_accept_all_then_bounce", func(r *Result) string { return strconv.FormatBool(r.SMTP.AcceptAllThenBounce) }},
+		{"smtp_deliverable", func(r *Result) string { return strconv.FormatBool(r.SMTP.Deliverable) }},
+		{"smtp_disabled", func(r *Result) string { return strconv.FormatBool(r.SMTP.Disabled) }},
+		{"smtp_mailbox_check_skipped", func(r *Result) string { return strconv.FormatBool(r.SMTP.MailboxCheckSkipped) }},
+	}},
+	{present: func(r *Result) bool { return r.Gravatar != nil }, columns: []csvColumn{
+		{"gravatar_has_gravatar", func(r *Result) string { return strconv.FormatBool(r.Gravatar.HasGravatar) }},
+		{"gravatar_url", func(r *Result) string { return r.Gravatar.GravatarUrl }},
+	}},
+}
+
+// CSVHeader returns the CSV header matching Result.MarshalCSVRecord
+func CSVHeader() []string {
+	var header []string
+	for _, group := range csvGroups {
+		for _, column := range group.columns {
+			header = append(header, column.name)
+		}
+	}
+	return header
+}
+
+// MarshalCSVRecord returns the Result as a CSV record with the columns of CSVHeader,
+// the columns of a nil SMTP or Gravatar are empty
+func (r *Result) MarshalCSVRecord() []string {
+	var record []string
+	for _, group := range csvGroups {
+		present := group.present == nil || group.present(r)
+		for _, column := range group.columns {
+			if present {
+				record = append(record, column.value(r))
+			} else {
+				record = append(record, "")
+			}
+		}
+	}
+	return record
+}
@@ -0,0 +1,70 @@
+package emailverifier
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalCSVRecord(t *testing.T) {
+	r := &Result{
+		Email:     "user@example.com",
+		Reachable: reachableYes,
+		Syntax:    Syntax{Username: "user", Domain: "example.com", Valid: true},
+		SMTP:      &SMTP{HostExists: true, CatchAllStatus: CatchAllNo, Deliverable: true},
+		Free:      true,
+	}
+
+	record := r.MarshalCSVRecord()
+	assert.Len(t, record, len(CSVHeader()))
+	assert.Equal(t, []string{
+		"user@example.com", "yes", "user", "example.com", "true", "", "", "false", "", "false", "", "true", "false", "", "", "", "false", "", "",
+		"false", "false", "false",
+		"true", "false", "false", "false", "no", "false", "true", "false", "false",
+		"", "",
+	}, record)
+}
+
+func TestMarshalCSVRecord_NilSMTP(t *testing.T) {
+	r := &Result{Email: "invalid", Reachable: reachableNo}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	assert.NoError(t, w.Write(CSVHeader()))
+	assert.NoError(t, w.Write(r.MarshalCSVRecord()))
+	w.Flush()
+	assert.NoError(t, w.Error())
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	// the smtp_* columns are together and empty
+	header := rows[0]
+	first := slices.Index(header, "smtp_host_exists")
+	last := slices.Index(header, "smtp_mailbox_check_skipped")
+	for i := first; i <= last; i++ {
+		assert.True(t, strings.HasPrefix(header[i], "smtp_"))
+		assert.Equal(t, "", rows[1][i])
+	}
+}
+
+func TestCSVHeader_IsCopy(t *testing.T) {
+	header := CSVHeader()
+	header[0] = "changed"
+	assert.Equal(t, "email", CSVHeader()[0])
+}
+
+func TestResultJSON_Deterministic(t *testing.T) {
+	r := &Result{Email: "user@example.com", SMTP: &SMTP{HostExists: true}}
+	first, err := json.Marshal(r)
+	assert.NoError(t, err)
+	second, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Contains(t, string(first), `"smtp":{"host_exists":true`)
+}
@@ -0,0 +1,39 @@
+package emailverifier
+
+import "sync/atomic"
+
+// defaultVerifier is used by the package-level functions, created on first use
+var defaultVerifier atomic.Pointer[Verifier]
+
+// DefaultVerifier returns the Verifier used by the package-level Verify, CheckSyntax
+// and IsDisposable functions. Unless replaced by SetDefaultVerifier, it is created
+// by NewVerifier on first use, so the SMTP check is disabled and Verify only looks
+// up the MX records of the domain.
+func DefaultVerifier() *Verifier {
+	if v := defaultVerifier.Load(); v != nil {
+		return v
+	}
+	defaultVerifier.CompareAndSwap(nil, NewVerifier())
+	return defaultVerifier.Load()
+}
+
+// SetDefaultVerifier replaces the Verifier used by the package-level functions,
+// nil restores a Verifier created by NewVerifier
+func SetDefaultVerifier(v *Verifier) {
+	defaultVerifier.Store(v)
+}
+
+// Verify verifies email with DefaultVerifier, see Verifier.Verify
+func Verify(email string) (*Result, error) {
+	return DefaultVerifier().Verify(email)
+}
+
+// CheckSyntax parses email with DefaultVerifier, see Verifier.ParseAddress
+func CheckSyntax(email string) Syntax {
+	return DefaultVerifier().ParseAddress(email)
+}
+
+// IsDisposable checks if domain is disposable with DefaultVerifier, see Verifier.IsDisposable
+func IsDisposable(domain string) bool {
+	return DefaultVerifier().IsDisposable(domain)
+}
@@ -0,0 +1,43 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultVerifier(t *testing.T) {
+	defer SetDefaultVerifier(nil)
+
+	v := DefaultVerifier()
+	assert.Same(t, v, DefaultVerifier())
+	assert.False(t, v.snapshot().smtpCheckEnabled)
+
+	assert.True(t, CheckSyntax("user@example.com").Valid)
+	assert.False(t, CheckSyntax("user@").Valid)
+	assert.True(t, IsDisposable("zzjbfwqi.shop"))
+	assert.False(t, IsDisposable("example.com"))
+
+	custom := NewVerifier().EnableSMTPCheck()
+	SetDefaultVerifier(custom)
+	assert.Same(t, custom, DefaultVerifier())
+
+	SetDefaultVerifier(nil)
+	assert.NotSame(t, custom, DefaultVerifier())
+}
+
+func TestVerify_DefaultVerifier(t *testing.T) {
+	defer SetDefaultVerifier(nil)
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	ret, err := Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ret.HasMxRecords)
+	assert.Nil(t, ret.SMTP)
+}
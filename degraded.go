@@ -0,0 +1,95 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Reasons of Result.DegradedReason, a primary check which couldn't run
+const (
+	// DegradedMXLookupFailed is an MX lookup which failed for another reason than
+	// the domain not existing, e.g. a DNS timeout
+	DegradedMXLookupFailed = "mx_lookup_failed"
+	// DegradedSMTPDisabled is a verification without the SMTP check, see EnableSMTPCheck
+	DegradedSMTPDisabled = "smtp_disabled"
+	// DegradedMailboxCheckSkipped is an SMTP check which didn't probe the mailbox, see EnableMXOnlyMode
+	DegradedMailboxCheckSkipped = "mailbox_check_skipped"
+	// DegradedSMTPUnreachable is an SMTP check which couldn't connect to any MX host,
+	// e.g. port 25 is filtered or the hosts refused the connection
+	DegradedSMTPUnreachable = "smtp_unreachable"
+	// DegradedSMTPTimeout is an SMTP check which timed out, e.g. all MX hosts timed out
+	DegradedSMTPTimeout = "smtp_timeout"
+	// DegradedProxyFailed is an SMTP check which failed to connect through the proxy
+	DegradedProxyFailed = "proxy_failed"
+	// DegradedSMTPRefused is an SMTP check refused by the MX host for all recipients,
+	// e.g. the IP of the verifier is blocklisted
+	DegradedSMTPRefused = "smtp_refused"
+	// DegradedSMTPTemporaryFailure is an SMTP check answered with a temporary failure,
+	// e.g. greylisting or a rate limit, which may succeed when retried later
+	DegradedSMTPTemporaryFailure = "smtp_temporary_failure"
+	// DegradedVerifyTimeout is a verification which ran out of its TotalTimeout before
+	// completing its checks
+	DegradedVerifyTimeout = "verify_timeout"
+)
+
+// setDegraded flags the Result as degraded for reason, an empty reason leaves it untouched
+func (r *Result) setDegraded(reason string) {
+	if reason == "" {
+		return
+	}
+	r.Degraded = true
+	r.DegradedReason = reason
+}
+
+// mxDegradedReason returns the DegradedReason of a failed MX lookup, empty when
+// the domain doesn't exist as this is a definitive answer
+func mxDegradedReason(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return ""
+	}
+	var lookupErr *LookupError
+	if errors.As(err, &lookupErr) && lookupErr.Message == ErrNoSuchHost {
+		return ""
+	}
+	return DegradedMXLookupFailed
+}
+
+// smtpDegradedReason returns the DegradedReason of the SMTP check returning s and err,
+// empty when the check gave a definitive answer, e.g. the mailbox doesn't exist
+func smtpDegradedReason(s *SMTP, err error) string {
+	if err == nil {
+		switch {
+		case s == nil:
+			return DegradedSMTPDisabled
+		case s.MailboxCheckSkipped:
+			return DegradedMailboxCheckSkipped
+		case !s.Deliverable && s.Error.Retryable():
+			return DegradedSMTPTemporaryFailure
+		}
+		return ""
+	}
+
+	var lookupErr *LookupError
+	if !errors.As(err, &lookupErr) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return DegradedSMTPTimeout
+		}
+		return DegradedSMTPUnreachable
+	}
+	// the verdict of the mailbox is recorded in SMTP.Error, an error means
+	// the check couldn't reach one
+	replied := replyCode(lookupErr.Details) >= 0
+	switch {
+	case !replied && isProxyError(errors.New(lookupErr.Details)):
+		return DegradedProxyFailed
+	case lookupErr.Message == ErrTimeout:
+		return DegradedSMTPTimeout
+	case replied && lookupErr.Temporary:
+		return DegradedSMTPTemporaryFailure
+	case replied:
+		return DegradedSMTPRefused
+	}
+	return DegradedSMTPUnreachable
+}
@@ -0,0 +1,157 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useFakeMX makes every domain resolve to the MX host "mx.<domain>." in Verify
+func useFakeMX() func() {
+	original := lookupMXContext
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	return func() { lookupMXContext = original }
+}
+
+// failDials makes every SMTP dial fail with err
+func failDials(err error) func() {
+	original := dialSMTPFunc
+	dialSMTPFunc = func(addr string, opts dialOptions) (*smtp.Client, error) {
+		return nil, err
+	}
+	return func() { dialSMTPFunc = original }
+}
+
+func TestVerify_DegradedSMTPDisabled(t *testing.T) {
+	defer useFakeMX()()
+
+	ret, err := NewVerifier().Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ret.Degraded)
+	assert.Equal(t, DegradedSMTPDisabled, ret.DegradedReason)
+}
+
+func TestVerify_DegradedSMTPFailures(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	defer useFakeMX()()
+
+	cases := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"port blocked", errors.New("dial tcp 192.0.2.1:25: connect: connection refused"), DegradedSMTPUnreachable},
+		{"timeout", errors.New("dial tcp 192.0.2.1:25: i/o timeout"), DegradedSMTPTimeout},
+		{"proxy", errors.New("socks connect tcp 127.0.0.1:1080->mx.example.com:25: dial tcp 127.0.0.1:1080: connect: connection refused"), DegradedProxyFailed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer failDials(c.err)()
+
+			ret, err := NewVerifier().EnableSMTPCheck().Verify("user@example.com")
+			assert.Error(t, err)
+			assert.True(t, ret.Degraded)
+			assert.Equal(t, c.reason, ret.DegradedReason)
+			assert.Equal(t, reachableUnknown, ret.Reachable)
+		})
+	}
+}
+
+func TestVerify_DegradedTemporaryFailure(t *testing.T) {
+	defer useFakeSMTPServer(t, func(cmd string) string {
+		if strings.HasPrefix(cmd, "RCPT") {
+			return "450 4.7.1 Greylisted, try again later"
+		}
+		return ""
+	})()
+	defer useFakeMX()()
+
+	ret, err := NewVerifier().EnableSMTPCheck().DisableCatchAllCheck().Verify("user@example.com")
+	assert.Error(t, err)
+	assert.True(t, ret.Degraded)
+	assert.Equal(t, DegradedSMTPTemporaryFailure, ret.DegradedReason)
+}
+
+func TestVerify_DefinitiveIsNotDegraded(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	defer useFakeMX()()
+
+	v := NewVerifier().EnableSMTPCheck()
+	for _, email := range []string{"user@example.com", "unknown@example.com", "invalid"} {
+		ret, err := v.Verify(email)
+		assert.NoError(t, err)
+		assert.False(t, ret.Degraded, email)
+		assert.Empty(t, ret.DegradedReason, email)
+	}
+
+	ret, err := v.Verify("unknown@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, reachableNo, ret.Reachable)
+}
+
+func TestVerify_DegradedMXLookupFailed(t *testing.T) {
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: domain, IsTimeout: true}
+	}
+	defer useResolvableHost()()
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+	}
+
+	ret, err := NewVerifier().EnableSMTPCheck().Verify("user@example.com")
+	assert.Error(t, err)
+	assert.Equal(t, DegradedMXLookupFailed, ret.DegradedReason)
+
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	ret, err = NewVerifier().EnableSMTPCheck().Verify("user@example.com")
+	assert.Error(t, err)
+	assert.False(t, ret.Degraded)
+}
+
+func TestVerify_DegradedVerifyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	defer useFakeSMTPServer(t, func(cmd string) string {
+		if strings.HasPrefix(cmd, "RCPT TO:<user@") {
+			<-release
+		}
+		return rejectRandomRcpt(cmd)
+	})()
+	defer useFakeMX()()
+
+	v := NewVerifier().EnableSMTPCheck().TotalTimeout(100 * time.Millisecond)
+	start := time.Now()
+	ret, err := v.Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.True(t, ret.Degraded)
+	assert.Equal(t, DegradedVerifyTimeout, ret.DegradedReason)
+	assert.Equal(t, reachableUnknown, ret.Reachable)
+	// the checks completed in time are kept
+	assert.True(t, ret.HasMxRecords)
+	assert.True(t, ret.Syntax.Valid)
+
+	// a deadline of the caller isn't reported as the total timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ret, _ = NewVerifier().EnableSMTPCheck().TotalTimeout(time.Minute).VerifyContext(ctx, "user@example.com")
+	assert.NotEqual(t, DegradedVerifyTimeout, ret.DegradedReason)
+
+	// a verification completed in time isn't degraded
+	ret, err = v.Verify("unknown@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.Degraded)
+	assert.Equal(t, reachableNo, ret.Reachable)
+}
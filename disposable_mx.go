@@ -0,0 +1,68 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+const (
+	// DisposableSourceList is the Result.DisposableSource of a domain on the disposable domains list
+	DisposableSourceList = "list"
+	// DisposableSourceMX is the Result.DisposableSource of a domain whose MX hosts
+	// belong to a disposable provider, see EnableDisposableMXHeuristic
+	DisposableSourceMX = "mx"
+)
+
+// disposableMXDomains are the domains of the MX hosts of disposable email providers,
+// which also receive the mail of the new domains these providers keep registering
+var disposableMXDomains = map[string]bool{
+	"mailinator.com":    true,
+	"guerrillamail.com": true,
+	"yopmail.com":       true,
+}
+
+// disposableMXSet is the concurrent safe set of disposableMXDomains, extended by AddDisposableMXDomains
+var disposableMXSet = newDomainSet(disposableMXDomains)
+
+// IsDisposableMX checks if host is an MX host of a disposable email provider, i.e. the
+// host or one of its parent domains is a disposable MX domain or a disposable domain
+// (e.g. "mail2.mailinator.com"). The match is case-insensitive.
+func (v *Verifier) IsDisposableMX(host string) bool {
+	host = cleanDomain(host)
+	for ; strings.Contains(host, "."); host = parentDomain(host) {
+		if disposableMXSet.contains(host) || disposableDomainSet.contains(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDisposableMX reports whether one of the MX records points at a disposable MX host
+func (v *Verifier) hasDisposableMX(records []*net.MX) bool {
+	for _, r := range records {
+		if v.IsDisposableMX(r.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// disposableSource returns how domain was found disposable, DisposableSourceList or
+// DisposableSourceMX, or an empty string when it isn't disposable. The MX records
+// are looked up through cache only when the heuristic is enabled and the domain
+// isn't on the list, a failed lookup isn't disposable.
+func (v *Verifier) disposableSource(ctx context.Context, domain string, cache *mxCache) string {
+	if disposableDomainSet.contains(cleanDomain(domain)) {
+		return DisposableSourceList
+	}
+	if !v.disposableMXHeuristic {
+		return ""
+	}
+	mx, err := cache.checkMX(ctx, v, domain)
+	if err != nil || mx.ImplicitMX || !v.hasDisposableMX(mx.Records) {
+		return ""
+	}
+	v.logger.Debug("MX hosts belong to a disposable provider", "domain", domain)
+	return DisposableSourceMX
+}
@@ -0,0 +1,62 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDisposableMX(t *testing.T) {
+	assert.True(t, verifier.IsDisposableMX("mail2.Mailinator.com."))
+	assert.True(t, verifier.IsDisposableMX("mx.guerrillamail.com"))
+	assert.False(t, verifier.IsDisposableMX("aspmx.l.google.com."))
+
+	v := NewVerifier().AddDisposableMXDomains("Throwaway-MX.Example.")
+	assert.True(t, v.IsDisposableMX("in.throwaway-mx.example"))
+	v.RemoveDisposableMXDomains("throwaway-mx.example")
+	assert.False(t, v.IsDisposableMX("in.throwaway-mx.example"))
+}
+
+func TestVerify_DisposableMXHeuristic(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookups := 0
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		lookups++
+		if domain == "fresh-throwaway.example" {
+			return []*net.MX{{Host: "mail.mailinator.com.", Pref: 10}}, nil
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	v := NewVerifier()
+	assert.False(t, v.IsDisposable("fresh-throwaway.example"))
+	ret, err := v.Verify("user@fresh-throwaway.example")
+	assert.NoError(t, err)
+	assert.False(t, ret.Disposable)
+	assert.Empty(t, ret.DisposableSource)
+
+	v.EnableDisposableMXHeuristic()
+	assert.True(t, v.IsDisposable("fresh-throwaway.example"))
+
+	lookups = 0
+	ret, err = v.Verify("user@fresh-throwaway.example")
+	assert.NoError(t, err)
+	assert.True(t, ret.Disposable)
+	assert.Equal(t, DisposableSourceMX, ret.DisposableSource)
+	assert.Equal(t, 1, lookups)
+
+	// the lookup of the heuristic is shared with the mx check
+	lookups = 0
+	ret, err = v.Verify("user@business.example")
+	assert.NoError(t, err)
+	assert.False(t, ret.Disposable)
+	assert.True(t, ret.HasMxRecords)
+	assert.Equal(t, 1, lookups)
+
+	ret, err = v.Verify("user@zzjbfwqi.shop")
+	assert.NoError(t, err)
+	assert.Equal(t, DisposableSourceList, ret.DisposableSource)
+}
@@ -0,0 +1,121 @@
+package emailverifier
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var lookupTXTContext = net.DefaultResolver.LookupTXT
+
+// DefaultDKIMSelectors are the selectors probed when no selector is passed to CheckDKIM
+var DefaultDKIMSelectors = []string{"google", "selector1", "selector2", "k1", "dkim", "default"}
+
+// DKIM is the DKIM key record published for a selector
+type DKIM struct {
+	Version string `json:"version"`  // v= tag, usually "DKIM1"
+	KeyType string `json:"key_type"` // k= tag, "rsa" when absent
+	HasKey  bool   `json:"has_key"`  // whether p= holds a key, an empty p= means the key is revoked
+	KeyBits int    `json:"key_bits"` // length of the key in bits, 0 when it can't be parsed
+	Raw     string `json:"-"`        // raw TXT record
+}
+
+// CheckDKIM queries the DKIM key record <selector>._domainkey.<domain> of every selector,
+// DefaultDKIMSelectors are probed when selectors is empty. A selector without a DKIM record
+// maps to a nil entry, errors are only returned when DNS can't be queried.
+func (v *Verifier) CheckDKIM(domain string, selectors []string) (map[string]*DKIM, error) {
+	domain = cleanDomain(domain)
+	if domain == "" {
+		return nil, errors.New("empty domain")
+	}
+	if len(selectors) == 0 {
+		selectors = DefaultDKIMSelectors
+	}
+	return checkDKIM(context.Background(), domain, selectors)
+}
+
+// checkDKIM looks up the TXT record of every selector of domain
+func checkDKIM(ctx context.Context, domain string, selectors []string) (map[string]*DKIM, error) {
+	keys := make(map[string]*DKIM, len(selectors))
+	for _, selector := range selectors {
+		records, err := lookupTXTContext(ctx, selector+"._domainkey."+domain)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				keys[selector] = nil
+				continue
+			}
+			return keys, fmt.Errorf("query DKIM selector %s: %w", selector, err)
+		}
+		keys[selector] = nil
+		for _, record := range records {
+			if key, ok := parseDKIM(record); ok {
+				keys[selector] = key
+				break
+			}
+		}
+	}
+	return keys, nil
+}
+
+// parseDKIM parses the v=, k= and p= tags of a DKIM key record,
+// false is returned when record isn't a DKIM key record
+func parseDKIM(record string) (*DKIM, bool) {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(record, ";") {
+		name, value, found := strings.Cut(tag, "=")
+		if !found {
+			continue
+		}
+		// values may be folded with whitespace, e.g. long keys split over several strings
+		tags[strings.ToLower(strings.TrimSpace(name))] = strings.Join(strings.Fields(value), "")
+	}
+	p, ok := tags["p"]
+	if !ok || (tags["v"] != "" && tags["v"] != "DKIM1") {
+		return nil, false
+	}
+
+	key := &DKIM{
+		Version: tags["v"],
+		KeyType: strings.ToLower(tags["k"]),
+		HasKey:  p != "",
+		Raw:     record,
+	}
+	if key.KeyType == "" {
+		key.KeyType = "rsa"
+	}
+	if key.HasKey {
+		key.KeyBits = dkimKeyBits(key.KeyType, p)
+	}
+	return key, true
+}
+
+// dkimKeyBits returns the length in bits of the base64 encoded public key p, 0 when it can't be parsed
+func dkimKeyBits(keyType, p string) int {
+	der, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return 0
+	}
+	if keyType == "ed25519" {
+		// ed25519 keys are published raw rather than as SubjectPublicKeyInfo
+		return len(der) * 8
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		// some publishers use the PKCS#1 encoding
+		rsaPub, pkcs1Err := x509.ParsePKCS1PublicKey(der)
+		if pkcs1Err != nil {
+			return 0
+		}
+		return rsaPub.N.BitLen()
+	}
+	if rsaPub, ok := pub.(*rsa.PublicKey); ok {
+		return rsaPub.N.BitLen()
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package emailverifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLookupTXT answers lookupTXTContext from records, other names are not found
+func stubLookupTXT(records map[string][]string) func() {
+	original := lookupTXTContext
+	lookupTXTContext = func(ctx context.Context, name string) ([]string, error) {
+		if txt, ok := records[name]; ok {
+			return txt, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return func() { lookupTXTContext = original }
+}
+
+func TestCheckDKIM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	defer stubLookupTXT(map[string][]string{
+		"google._domainkey.example.com":    {"v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)},
+		"selector1._domainkey.example.com": {"v=spf1 -all", "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(edPub)},
+		"selector2._domainkey.example.com": {"v=DKIM1; p="},
+		"k1._domainkey.example.com":        {"some unrelated record"},
+	})()
+
+	keys, err := verifier.CheckDKIM("Example.com.", nil)
+	assert.NoError(t, err)
+	assert.Len(t, keys, len(DefaultDKIMSelectors))
+
+	assert.Equal(t, "DKIM1", keys["google"].Version)
+	assert.Equal(t, "rsa", keys["google"].KeyType)
+	assert.True(t, keys["google"].HasKey)
+	assert.Equal(t, 1024, keys["google"].KeyBits)
+
+	assert.Equal(t, "ed25519", keys["selector1"].KeyType)
+	assert.Equal(t, 256, keys["selector1"].KeyBits)
+
+	// revoked key
+	assert.Equal(t, "rsa", keys["selector2"].KeyType)
+	assert.False(t, keys["selector2"].HasKey)
+	assert.Equal(t, 0, keys["selector2"].KeyBits)
+
+	for _, selector := range []string{"k1", "dkim", "default"} {
+		key, ok := keys[selector]
+		assert.True(t, ok)
+		assert.Nil(t, key)
+	}
+}
+
+func TestCheckDKIM_Errors(t *testing.T) {
+	_, err := verifier.CheckDKIM(" ", nil)
+	assert.Error(t, err)
+
+	original := lookupTXTContext
+	defer func() { lookupTXTContext = original }()
+	lookupTXTContext = func(ctx context.Context, name string) ([]string, error) {
+		return nil, errors.New("i/o timeout")
+	}
+	_, err = verifier.CheckDKIM("example.com", []string{"s1"})
+	assert.Error(t, err)
+}
+
+func TestParseDKIM(t *testing.T) {
+	key, ok := parseDKIM("v=DKIM1; k=rsa; p=not base64!")
+	assert.True(t, ok)
+	assert.True(t, key.HasKey)
+	assert.Equal(t, 0, key.KeyBits)
+
+	_, ok = parseDKIM("v=DKIM2; p=abc")
+	assert.False(t, ok)
+}
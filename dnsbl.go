@@ -0,0 +1,87 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var (
+	// DefaultDNSBLZones are the IP based blocklists queried when no zone is passed to CheckDNSBL
+	DefaultDNSBLZones = []string{"zen.spamhaus.org", "bl.spamcop.net"}
+	// DefaultDomainDNSBLZones are the domain based blocklists queried when no zone is passed to CheckDomainDNSBL
+	DefaultDomainDNSBLZones = []string{"dbl.spamhaus.org"}
+)
+
+// CheckDNSBL queries the DNSBL zones for ip, e.g. the public IP SMTP checks are sent from,
+// and returns whether each zone lists it. DefaultDNSBLZones are queried when zones is empty.
+// This helps telling whether ErrBlocked is about the reputation of the sender.
+func (v *Verifier) CheckDNSBL(ip net.IP, zones []string) (map[string]bool, error) {
+	name := reverseIP(ip)
+	if name == "" {
+		return nil, fmt.Errorf("invalid IP address: %v", ip)
+	}
+	if len(zones) == 0 {
+		zones = DefaultDNSBLZones
+	}
+	return checkDNSBL(context.Background(), name, zones)
+}
+
+// CheckDomainDNSBL queries the domain based DNSBL zones for domain and returns
+// whether each zone lists it. DefaultDomainDNSBLZones are queried when zones is empty.
+func (v *Verifier) CheckDomainDNSBL(domain string, zones []string) (map[string]bool, error) {
+	domain = cleanDomain(domain)
+	if domain == "" {
+		return nil, errors.New("empty domain")
+	}
+	if len(zones) == 0 {
+		zones = DefaultDomainDNSBLZones
+	}
+	return checkDNSBL(context.Background(), domain, zones)
+}
+
+// checkDNSBL looks up the A record of name in every zone, a zone lists name when
+// the record exists. Spamhaus answers 127.255.255.x to refused queries
+// (e.g. through public resolvers), which are reported as errors.
+func checkDNSBL(ctx context.Context, name string, zones []string) (map[string]bool, error) {
+	listed := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		zone = strings.Trim(zone, ".")
+		addrs, err := lookupHostContext(ctx, name+"."+zone)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				listed[zone] = false
+				continue
+			}
+			return listed, fmt.Errorf("query DNSBL %s: %w", zone, err)
+		}
+		for _, addr := range addrs {
+			if strings.HasPrefix(addr, "127.255.255.") {
+				return listed, fmt.Errorf("query DNSBL %s: refused with %s", zone, addr)
+			}
+		}
+		listed[zone] = len(addrs) > 0
+	}
+	return listed, nil
+}
+
+// reverseIP returns the DNSBL query name of ip: the reversed octets of an IPv4
+// address or the reversed nibbles of an IPv6 address, empty when ip is invalid
+func reverseIP(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return ""
+	}
+	const hexDigits = "0123456789abcdef"
+	nibbles := make([]string, 0, 32)
+	for i := len(ip16) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hexDigits[ip16[i]&0xf]), string(hexDigits[ip16[i]>>4]))
+	}
+	return strings.Join(nibbles, ".")
+}
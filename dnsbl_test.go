@@ -0,0 +1,73 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLookupHost answers lookupHostContext from records, other hosts are not found
+func stubLookupHost(records map[string][]string) func() {
+	original := lookupHostContext
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		if addrs, ok := records[host]; ok {
+			return addrs, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return func() { lookupHostContext = original }
+}
+
+func TestReverseIP(t *testing.T) {
+	assert.Equal(t, "4.3.2.1", reverseIP(net.ParseIP("1.2.3.4")))
+	assert.Equal(t,
+		"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2",
+		reverseIP(net.ParseIP("2001:db8::1")))
+	assert.Equal(t, "", reverseIP(nil))
+}
+
+func TestCheckDNSBL(t *testing.T) {
+	defer stubLookupHost(map[string][]string{
+		"2.0.0.127.zen.spamhaus.org": {"127.0.0.2"},
+	})()
+
+	listed, err := verifier.CheckDNSBL(net.ParseIP("127.0.0.2"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"zen.spamhaus.org": true, "bl.spamcop.net": false}, listed)
+}
+
+func TestCheckDNSBL_Refused(t *testing.T) {
+	defer stubLookupHost(map[string][]string{
+		"2.0.0.127.zen.spamhaus.org": {"127.255.255.254"},
+	})()
+
+	_, err := verifier.CheckDNSBL(net.ParseIP("127.0.0.2"), []string{"zen.spamhaus.org"})
+	assert.Error(t, err)
+}
+
+func TestCheckDNSBL_LookupError(t *testing.T) {
+	original := lookupHostContext
+	defer func() { lookupHostContext = original }()
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return nil, errors.New("i/o timeout")
+	}
+
+	_, err := verifier.CheckDNSBL(net.ParseIP("192.0.2.1"), []string{"bl.example.org"})
+	assert.Error(t, err)
+}
+
+func TestCheckDomainDNSBL(t *testing.T) {
+	defer stubLookupHost(map[string][]string{
+		"spam.example.dbl.spamhaus.org": {"127.0.1.2"},
+	})()
+
+	listed, err := verifier.CheckDomainDNSBL("Spam.Example.", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"dbl.spamhaus.org": true}, listed)
+
+	_, err = verifier.CheckDomainDNSBL("", nil)
+	assert.Error(t, err)
+}
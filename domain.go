@@ -0,0 +1,58 @@
+package emailverifier
+
+import "context"
+
+// DomainResult is the outcome of CheckDomain, it tells whether a domain receives
+// mail regardless of any mailbox
+type DomainResult struct {
+	Domain         string         `json:"domain"`               // checked domain
+	AcceptsMail    bool           `json:"accepts_mail"`         // the domain has MX records, or an A/AAAA record used as implicit MX
+	HostExists     bool           `json:"host_exists"`          // an MX host accepted the connection and the MAIL FROM
+	CatchAll       bool           `json:"catch_all"`            // the catch-all probe was accepted, i.e. CatchAllStatus is CatchAllYes
+	CatchAllStatus CatchAllStatus `json:"catch_all_status"`     // the outcome of the catch-all probe, CatchAllUnknown when the host wasn't reached
+	MXRecords      []MXRecord     `json:"mx_records,omitempty"` // MX records of the domain sorted by preference
+	SMTP           *SMTP          `json:"smtp,omitempty"`       // details of the SMTP check, nil when the domain doesn't accept mail
+}
+
+// CheckDomain checks whether domain receives mail, independently of any mailbox:
+// its MX records are looked up, then an MX host is greeted and probed with a random
+// address for a catch-all, the same as CheckSMTP with an empty username. It is meant
+// to pre-qualify a domain before verifying many addresses on it. The SMTP and
+// catch-all checks run even when they are disabled on the Verifier, the API verifiers
+// and the registered MailboxCheckers are skipped as they check a mailbox. The result is filled in as far as the checks
+// went when an error is returned.
+func (v *Verifier) CheckDomain(domain string) (*DomainResult, error) {
+	v = v.snapshot()
+	return v.checkDomain(context.Background(), domain)
+}
+
+// checkDomain is CheckDomain bound to ctx
+func (v *Verifier) checkDomain(ctx context.Context, domain string) (*DomainResult, error) {
+	ret := &DomainResult{Domain: domain, CatchAllStatus: CatchAllUnknown}
+	mx, err := v.checkMX(ctx, domain)
+	if err != nil {
+		return ret, err
+	}
+	ret.AcceptsMail = mx.HasMXRecord || mx.ImplicitMX
+	ret.MXRecords = mx.MXRecords
+	if !ret.AcceptsMail {
+		return ret, nil
+	}
+
+	// v is a snapshot, the settings only change for this check
+	v.smtpCheckEnabled = true
+	v.catchAllCheckEnabled = true
+	v.mxOnlyMode = false
+	v.apiVerifiers = nil
+	v.apiDomains = nil
+	v.mailboxCheckers = nil
+	v.mailboxFallback = nil
+	smtp, err := v.checkSMTP(ctx, domain, "")
+	if smtp != nil {
+		ret.SMTP = smtp
+		ret.HostExists = smtp.HostExists
+		ret.CatchAllStatus = smtp.CatchAllStatus
+		ret.CatchAll = smtp.CatchAllStatus == CatchAllYes
+	}
+	return ret, err
+}
@@ -0,0 +1,203 @@
+package emailverifier
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// domainSet is a set of domains which is safe for concurrent use.
+// The base domains come from metadata (or a remote source) and can be
+// swapped atomically, domains added or removed at runtime survive such swaps.
+// Entries in the form of "*.example.com" match any subdomain of example.com,
+// entries in the form of "/regexp/" match the domains matching the regexp.
+// Plain domains are looked up in a map, the patterns are only evaluated on a miss.
+type domainSet struct {
+	mu            sync.RWMutex
+	base          map[string]struct{}       // domains loaded from metadata or a remote source
+	baseWildcards map[string]struct{}       // parent domains of the wildcard entries of base
+	baseRegexps   []*regexp.Regexp          // regexp entries of base
+	added         map[string]struct{}       // domains added at runtime by users of this library
+	removed       map[string]struct{}       // domains and wildcard entries removed at runtime by users of this library
+	wildcards     map[string]struct{}       // parent domains whose subdomains are all in the set
+	regexps       map[string]*regexp.Regexp // regexps added at runtime, keyed by their source
+}
+
+// newDomainSet creates a domainSet with the given base domains
+func newDomainSet(domains map[string]bool) *domainSet {
+	entries := make(map[string]struct{}, len(domains))
+	for d := range domains {
+		entries[d] = struct{}{}
+	}
+	s := &domainSet{
+		added:     map[string]struct{}{},
+		removed:   map[string]struct{}{},
+		wildcards: map[string]struct{}{},
+		regexps:   map[string]*regexp.Regexp{},
+	}
+	s.replace(entries)
+	return s
+}
+
+// contains reports whether domain is in the set
+func (s *domainSet) contains(domain string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, found := s.removed[domain]; found {
+		return false
+	}
+	if _, found := s.added[domain]; found {
+		return true
+	}
+	if _, found := s.base[domain]; found {
+		return true
+	}
+	if len(s.wildcards) > 0 || len(s.baseWildcards) > 0 {
+		for parent := parentDomain(domain); parent != ""; parent = parentDomain(parent) {
+			if _, found := s.wildcards[parent]; found {
+				return true
+			}
+			if _, found := s.baseWildcards[parent]; found {
+				if _, removed := s.removed["*."+parent]; !removed {
+					return true
+				}
+			}
+		}
+	}
+	for _, re := range s.baseRegexps {
+		if re.MatchString(domain) {
+			return true
+		}
+	}
+	for _, re := range s.regexps {
+		if re.MatchString(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// add adds domains to the set, they are kept when the base domains are replaced
+func (s *domainSet) add(domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range domains {
+		delete(s.removed, d)
+		if parent, ok := wildcardParent(d); ok {
+			s.wildcards[parent] = struct{}{}
+			continue
+		}
+		s.added[d] = struct{}{}
+	}
+}
+
+// addRegexps adds regexps matching domains of the set, they are kept when the base domains are replaced
+func (s *domainSet) addRegexps(patterns ...*regexp.Regexp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, re := range patterns {
+		s.regexps[re.String()] = re
+	}
+}
+
+// removeRegexps removes regexps added by addRegexps, they are matched by their source
+func (s *domainSet) removeRegexps(patterns ...*regexp.Regexp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, re := range patterns {
+		delete(s.regexps, re.String())
+	}
+}
+
+// remove removes domains from the set, they stay removed when the base domains are replaced
+func (s *domainSet) remove(domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range domains {
+		if parent, ok := wildcardParent(d); ok {
+			delete(s.wildcards, parent)
+		} else {
+			delete(s.added, d)
+		}
+		s.removed[d] = struct{}{}
+	}
+}
+
+// replace atomically swaps the base domains of the set, a regexp entry
+// which doesn't compile is skipped, see checkDomainEntries
+func (s *domainSet) replace(domains map[string]struct{}) {
+	base := make(map[string]struct{}, len(domains))
+	wildcards := map[string]struct{}{}
+	var regexps []*regexp.Regexp
+	for d := range domains {
+		if parent, ok := wildcardParent(d); ok {
+			wildcards[parent] = struct{}{}
+			continue
+		}
+		if isRegexpEntry(d) {
+			if re, err := compileRegexpEntry(d); err == nil {
+				regexps = append(regexps, re)
+			}
+			continue
+		}
+		base[d] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.base = base
+	s.baseWildcards = wildcards
+	s.baseRegexps = regexps
+}
+
+// wildcardParent returns the parent domain of a "*.example.com" entry
+func wildcardParent(domain string) (string, bool) {
+	if strings.HasPrefix(domain, "*.") && len(domain) > 2 {
+		return domain[2:], true
+	}
+	return "", false
+}
+
+// isRegexpEntry reports whether entry is a regexp in the form of "/regexp/"
+func isRegexpEntry(entry string) bool {
+	return len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/")
+}
+
+// compileRegexpEntry compiles the regexp of a "/regexp/" entry
+func compileRegexpEntry(entry string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(entry[1 : len(entry)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp entry %s: %w", entry, err)
+	}
+	return re, nil
+}
+
+// checkDomainEntries returns an error for the first regexp entry which doesn't compile
+func checkDomainEntries(entries map[string]struct{}) error {
+	for entry := range entries {
+		if isRegexpEntry(entry) {
+			if _, err := compileRegexpEntry(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cleanDomainEntry normalizes a domain or wildcard entry with cleanDomain,
+// a regexp entry is only trimmed as lowercasing it would change its meaning
+func cleanDomainEntry(entry string) string {
+	if trimmed := strings.TrimSpace(entry); isRegexpEntry(trimmed) {
+		return trimmed
+	}
+	return cleanDomain(entry)
+}
+
+// parentDomain strips the leftmost label of domain, returns "" for a top level domain
+func parentDomain(domain string) string {
+	if i := strings.Index(domain, "."); i >= 0 {
+		return domain[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package emailverifier
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainSet_Contains(t *testing.T) {
+	s := newDomainSet(map[string]bool{"a.com": true})
+	assert.True(t, s.contains("a.com"))
+	assert.False(t, s.contains("b.com"))
+}
+
+func TestDomainSet_ReplaceKeepsAdded(t *testing.T) {
+	s := newDomainSet(map[string]bool{"a.com": true})
+	s.add("b.com")
+
+	s.replace(map[string]struct{}{"c.com": {}})
+	assert.False(t, s.contains("a.com"))
+	assert.True(t, s.contains("b.com"))
+	assert.True(t, s.contains("c.com"))
+}
+
+func TestDomainSet_RemoveSurvivesReplace(t *testing.T) {
+	s := newDomainSet(map[string]bool{"a.com": true, "b.com": true})
+	s.remove("a.com")
+	assert.False(t, s.contains("a.com"))
+
+	s.replace(map[string]struct{}{"a.com": {}})
+	assert.False(t, s.contains("a.com"))
+
+	s.add("a.com")
+	assert.True(t, s.contains("a.com"))
+}
+
+func TestDomainSet_Wildcard(t *testing.T) {
+	s := newDomainSet(nil)
+	s.add("*.tempmail.com")
+
+	assert.True(t, s.contains("abc.tempmail.com"))
+	assert.True(t, s.contains("x.abc.tempmail.com"))
+	assert.False(t, s.contains("tempmail.com"))
+	assert.False(t, s.contains("nottempmail.com"))
+
+	s.remove("*.tempmail.com")
+	assert.False(t, s.contains("abc.tempmail.com"))
+}
+
+func TestDomainSet_BasePatterns(t *testing.T) {
+	s := newDomainSet(map[string]bool{"a.com": true, "*.tempmail.com": true, `/^mail[0-9]+\.tk$/`: true, "/[/": true})
+
+	assert.True(t, s.contains("a.com"))
+	assert.True(t, s.contains("abc.tempmail.com"))
+	assert.False(t, s.contains("tempmail.com"))
+	assert.True(t, s.contains("mail7.tk"))
+	assert.False(t, s.contains("mail.tk"))
+	assert.False(t, s.contains("/[/"))
+
+	s.remove("*.tempmail.com", "mail8.tk")
+	assert.False(t, s.contains("abc.tempmail.com"))
+	assert.False(t, s.contains("mail8.tk"))
+	assert.True(t, s.contains("mail7.tk"))
+
+	s.replace(map[string]struct{}{"*.tempmail.com": {}})
+	assert.False(t, s.contains("abc.tempmail.com"))
+	assert.False(t, s.contains("mail7.tk"))
+
+	s.add("*.tempmail.com")
+	assert.True(t, s.contains("abc.tempmail.com"))
+}
+
+func TestDomainSet_Regexps(t *testing.T) {
+	s := newDomainSet(nil)
+	re := regexp.MustCompile(`^mail[0-9]+\.tk$`)
+	s.addRegexps(re)
+	assert.True(t, s.contains("mail7.tk"))
+
+	s.replace(map[string]struct{}{"a.com": {}})
+	assert.True(t, s.contains("mail7.tk"))
+
+	s.removeRegexps(re)
+	assert.False(t, s.contains("mail7.tk"))
+}
+
+func TestParentDomain(t *testing.T) {
+	assert.Equal(t, "example.com", parentDomain("a.example.com"))
+	assert.Equal(t, "com", parentDomain("example.com"))
+	assert.Equal(t, "", parentDomain("com"))
+}
@@ -0,0 +1,58 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDomain(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	originalLookupHost := lookupHostContext
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupHostContext = originalLookupHost
+	}()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		if domain == "nomail.example" {
+			return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	// the SMTP and catch-all checks run even when disabled on the verifier
+	v := NewVerifier().DisableCatchAllCheck()
+
+	t.Run("not catch-all", func(t *testing.T) {
+		defer useFakeSMTPServer(t, rejectRandomRcpt)()
+		ret, err := v.CheckDomain("example.com")
+		assert.NoError(t, err)
+		assert.True(t, ret.AcceptsMail)
+		assert.True(t, ret.HostExists)
+		assert.False(t, ret.CatchAll)
+		assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+		assert.Equal(t, []MXRecord{{Host: "mx.example.com.", Pref: 10}}, ret.MXRecords)
+	})
+
+	t.Run("catch-all", func(t *testing.T) {
+		defer useFakeSMTPServer(t, func(string) string { return "" })()
+		ret, err := v.CheckDomain("example.com")
+		assert.NoError(t, err)
+		assert.True(t, ret.HostExists)
+		assert.True(t, ret.CatchAll)
+		assert.Equal(t, CatchAllYes, ret.CatchAllStatus)
+	})
+
+	t.Run("no mail", func(t *testing.T) {
+		ret, err := v.CheckDomain("nomail.example")
+		assert.Error(t, err)
+		assert.False(t, ret.AcceptsMail)
+		assert.False(t, ret.HostExists)
+		assert.Nil(t, ret.SMTP)
+	})
+}
@@ -1,11 +1,15 @@
 package emailverifier
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net/textproto"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 )
 
 const (
@@ -17,7 +21,7 @@ const (
 
 	// RCPT Errors
 	ErrTryAgainLater           = "Try again later"
-	ErrFullInbox               = "Recipient out of disk space"
+	ErrFullInbox               = "Recipient out of disk space" // temporary (4xx) or permanent (5xx), see SMTP.FullInboxPermanent
 	ErrTooManyRCPT             = "Too many recipients"
 	ErrNoRelay                 = "Not an open relay"
 	ErrMailboxBusy             = "Mailbox busy"
@@ -26,43 +30,208 @@ const (
 	ErrNeedMAILBeforeRCPT      = "Need MAIL before RCPT"
 	ErrRCPTHasMoved            = "Recipient has moved"
 	ErrMailboxNotFound         = "Mailbox not found"
+	ErrMailboxDisabled         = "Mailbox disabled"
 	ErrTLSVersion              = "TLS version not supported"
+	// ErrConnectionDropped is a connection reset by the server during the RCPT stage,
+	// which some providers do to tarpit senders rather than replying
+	ErrConnectionDropped = "Connection dropped by mail server"
+	// ErrMessageTooLarge is a rejection of the size of the message, see SMTPOptions.ExpectedSize
+	ErrMessageTooLarge = "Message exceeds the size limit"
 )
 
+// errNullMX is returned when dialing a domain publishing a null MX (RFC 7505),
+// ParseSMTPError maps it to ErrNoSuchHost
+var errNullMX = errors.New("domain does not accept mail (null MX)")
+
+// SMTP stages recorded in LookupError.Stage when the server resets the connection
+const (
+	SMTPStageConnect = "connect" // dialing and reading the greeting
+	SMTPStageEHLO    = "ehlo"    // EHLO/HELO
+	SMTPStageMAIL    = "mail"    // MAIL FROM
+	SMTPStageRCPT    = "rcpt"    // RCPT TO
+	SMTPStageAuth    = "auth"    // STARTTLS and AUTH with a submission relay, see SubmissionRelay
+)
+
+// EnhancedStatusCodes maps RFC 3463 enhanced status codes to the message of the LookupError
+// returned by ParseSMTPError. A reply carrying one of these codes is mapped by the code,
+// regardless of its text. Codes which are used for unrelated rejections in the wild,
+// such as 5.7.1 for both relaying and spam policies, are left to the text heuristics.
+// The table may be extended before the verifier is used, it must not be modified concurrently.
+var EnhancedStatusCodes = map[string]string{
+	"5.1.1":  ErrMailboxNotFound, // bad destination mailbox address
+	"5.1.2":  ErrNoSuchHost,      // bad destination system address
+	"5.1.6":  ErrRCPTHasMoved,    // destination mailbox has moved, no forwarding address
+	"5.1.10": ErrNoSuchHost,      // recipient address has null MX (RFC 7505)
+	"5.2.1":  ErrMailboxDisabled, // mailbox disabled, not accepting messages
+	"4.2.2":  ErrFullInbox,       // mailbox full
+	"5.2.2":  ErrFullInbox,       // mailbox full
+	"5.2.3":  ErrMessageTooLarge, // message length exceeds administrative limit
+	"5.3.4":  ErrMessageTooLarge, // message too big for system
+	"4.5.3":  ErrTooManyRCPT,     // too many recipients
+	"5.5.3":  ErrTooManyRCPT,     // too many recipients
+	"5.7.25": ErrBlocked,         // reverse DNS validation failed
+	"5.7.26": ErrBlocked,         // multiple authentication checks failed
+}
+
+// enhancedStatusCodePattern matches the enhanced status code following the reply code
+var enhancedStatusCodePattern = regexp.MustCompile(`^([245])\d\d[ -]([245]\.\d{1,3}\.\d{1,3})(?:[^\d.]|$)`)
+
+// EnhancedStatusCode returns the RFC 3463 enhanced status code of an SMTP reply,
+// e.g. "5.1.1" for "550 5.1.1 user unknown". It is empty when the reply has no
+// enhanced code or its class doesn't match the class of the reply code.
+func EnhancedStatusCode(reply string) string {
+	match := enhancedStatusCodePattern.FindStringSubmatch(reply)
+	if match == nil || match[1][0] != match[2][0] {
+		return ""
+	}
+	return match[2]
+}
+
+// temporaryErrors are the messages of errors which are worth retrying later
+// when they don't come with an SMTP reply code
+var temporaryErrors = map[string]bool{
+	ErrTimeout:                 true,
+	ErrServerUnavailable:       true,
+	ErrTryAgainLater:           true,
+	ErrMailboxBusy:             true,
+	ErrExceededMessagingLimits: true,
+	ErrConnectionDropped:       true,
+}
+
 // LookupError is an MX dns records lookup error
 type LookupError struct {
-	Message string `json:"message" xml:"message"`
-	Details string `json:"details" xml:"details"`
+	Message   string `json:"message" xml:"message"`
+	Details   string `json:"details" xml:"details"`
+	Temporary bool   `json:"temporary" xml:"temporary"` // whether the error is transient, see Retryable
+	// Stage is the SMTP stage (e.g. SMTPStageEHLO) at which the server reset the
+	// connection, empty for other errors. A reset after EHLO hints at tarpitting
+	// rather than a dead host.
+	Stage string `json:"stage,omitempty" xml:"stage,omitempty"`
 }
 
-// newLookupError creates a new LookupError reference and returns it
+// newLookupError creates a new LookupError reference and returns it,
+// it is temporary when message is one of the temporaryErrors
 func newLookupError(message, details string) *LookupError {
-	return &LookupError{message, details}
+	return &LookupError{Message: message, Details: details, Temporary: temporaryErrors[message]}
+}
+
+// Retryable reports whether the lookup may succeed when retried later
+// (e.g. greylisting, a busy mailbox or a rate limit) rather than failing
+// permanently (e.g. a mailbox not found or a relay denied)
+func (e *LookupError) Retryable() bool {
+	return e != nil && e.Temporary
 }
 
 func (e *LookupError) Error() string {
 	return fmt.Sprintf("%s : %s", e.Message, e.Details)
 }
 
+// stageError is an error of the SMTP conversation annotated with the stage it happened at
+type stageError struct {
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string { return e.err.Error() }
+
+func (e *stageError) Unwrap() error { return e.err }
+
+// withStage annotates err with the SMTP stage it happened at, nil stays nil
+func withStage(stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stageError{stage: stage, err: err}
+}
+
+// isConnectionReset reports whether err is a connection reset by the peer
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || insContains(err.Error(), "connection reset")
+}
+
 // ParseSMTPError receives an MX Servers response message
-// and generates the corresponding MX error
+// and generates the corresponding MX error. The error of a reply is
+// temporary when its code is 4xx and permanent when it is 5xx.
+// The lines of a multiline reply are all matched, the status code is
+// read from the final line and the enhanced status code from the last
+// line carrying one. A connection reset records the stage of the
+// conversation in Stage and is ErrConnectionDropped during the RCPT stage.
 func ParseSMTPError(err error) *LookupError {
-	errStr := err.Error()
+	var stageErr *stageError
+	if errors.As(err, &stageErr) && isConnectionReset(err) {
+		e := parseBasicErr(err)
+		if stageErr.stage == SMTPStageRCPT {
+			e = newLookupError(ErrConnectionDropped, err.Error())
+		}
+		e.Stage = stageErr.stage
+		return e
+	}
+
+	lines := replyLines(err)
 
-	// Verify the length of the error before reading nil indexes
-	if len(errStr) < 3 {
+	// Strips out the status code string of the final reply line and converts to an integer for parsing
+	status := -1
+	for i := len(lines) - 1; i >= 0 && status < 0; i-- {
+		status = replyCode(lines[i])
+	}
+	if status < 0 {
 		return parseBasicErr(err)
 	}
 
-	// Strips out the status code string and converts to an integer for parsing
-	status, convErr := strconv.Atoi(string([]rune(errStr)[0:3]))
-	if convErr != nil {
-		return parseBasicErr(err)
+	e := parseReplyError(err, lines, status)
+	if e != nil {
+		e.Temporary = status < 500
+	}
+	return e
+}
+
+// replyLines splits the SMTP reply of err into its lines. The lines of a
+// textproto.Error get back the reply code which the client stripped.
+func replyLines(err error) []string {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		lines := strings.Split(tpErr.Msg, "\n")
+		for i, line := range lines {
+			lines[i] = fmt.Sprintf("%03d %s", tpErr.Code, line)
+		}
+		return lines
+	}
+	lines := strings.Split(err.Error(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// replyCode returns the status code at the start of an SMTP reply line, -1 when it has none
+func replyCode(line string) int {
+	if len(line) < 3 {
+		return -1
+	}
+	code, err := strconv.Atoi(line[:3])
+	if err != nil || code < 0 {
+		return -1
+	}
+	return code
+}
+
+// parseReplyError generates the MX error of the SMTP reply lines with the status code
+func parseReplyError(err error, lines []string, status int) *LookupError {
+	errStr := err.Error()
+	text := strings.Join(lines, "\n")
+
+	// enhanced status codes are machine-reliable, so they take precedence over the text
+	if status >= 400 {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if message, ok := EnhancedStatusCodes[EnhancedStatusCode(lines[i])]; ok {
+				return newLookupError(message, errStr)
+			}
+		}
 	}
 
 	// status code is 4xx - generally soft bounces or greylist responses
 	if status >= 400 && status < 500 {
-		if insContains(errStr, "greylist") {
+		if insContains(text, greylistPhrases.load()...) {
 			return newLookupError(ErrTryAgainLater, errStr)
 		}
 
@@ -74,10 +243,11 @@ func ParseSMTPError(err error) *LookupError {
 		case 451:
 			return newLookupError(ErrExceededMessagingLimits, errStr)
 		case 452:
-			if insContains(errStr,
+			if insContains(text,
 				"full",
 				"space",
-				"over quota",
+				"quota",
+				"storage",
 				"insufficient",
 			) {
 				return newLookupError(ErrFullInbox, errStr)
@@ -90,18 +260,7 @@ func ParseSMTPError(err error) *LookupError {
 
 	// status code is 5xx - generally hard bounces or the server is blocking us
 	if status >= 500 {
-		if insContains(errStr,
-			"undeliverable",
-			"does not exist",
-			"may not exist",
-			"user unknown",
-			"user not found",
-			"invalid address",
-			"recipient invalid",
-			"recipient rejected",
-			"address rejected",
-			"no mailbox",
-			"no mail-enabled") {
+		if insContains(text, mailboxNotFoundPhrases.load()...) {
 			return newLookupError(ErrMailboxNotFound, errStr) // These errors indicate the address doesn't exist, not a server problem
 		}
 
@@ -109,29 +268,43 @@ func ParseSMTPError(err error) *LookupError {
 		case 503:
 			return newLookupError(ErrNeedMAILBeforeRCPT, errStr)
 		case 550: // 550 is Mailbox Unavailable - usually undeliverable, ref: https://blog.mailtrap.io/550-5-1-1-rejected-fix/
-			if insContains(errStr,
-				"spamhaus",
-				"proofpoint",
-				"cloudmark",
-				"banned",
-				"blacklisted",
-				"blocked",
-				"block list",
-				"denied") {
+			// the mailbox exists but doesn't accept mail for now
+			if insContains(text,
+				"mailbox disabled",
+				"account disabled",
+				"account has been disabled",
+				"mailbox has been disabled") {
+				return newLookupError(ErrMailboxDisabled, errStr)
+			}
+			if insContains(text, "quota") {
+				return newLookupError(ErrFullInbox, errStr)
+			}
+			// checked before the block list, as "denied" means a policy rather than a reputation problem here
+			if insContains(text,
+				"relay access denied",
+				"relaying denied",
+				"relay not permitted",
+				"unable to relay") {
+				return newLookupError(ErrNoRelay, errStr)
+			}
+			if insContains(text, blockedPhrases.load()...) {
 				return newLookupError(ErrBlocked, errStr)
 			}
-			if insContains(errStr, "tls version") {
+			if insContains(text, "tls version") {
 				return newLookupError(ErrTLSVersion, errStr)
 			}
 			return newLookupError(ErrMailboxNotFound, errStr)
 		case 551:
 			return newLookupError(ErrRCPTHasMoved, errStr)
 		case 552:
+			if insContains(text, "message size", "size limit", "too large", "too big", "message length") {
+				return newLookupError(ErrMessageTooLarge, errStr)
+			}
 			return newLookupError(ErrFullInbox, errStr)
 		case 553:
 			return newLookupError(ErrNoRelay, errStr)
 		case 554:
-			if insContains(errStr, "relay access denied") {
+			if insContains(text, "relay access denied") {
 				return newLookupError(ErrNoRelay, errStr)
 			}
 			return newLookupError(ErrNotAllowed, errStr)
@@ -153,15 +326,11 @@ func parseBasicErr(err error) *LookupError {
 	switch {
 	case errors.Is(err, io.EOF):
 		return newLookupError(ErrServerUnavailable, errStr)
-	case insContains(errStr,
-		"spamhaus",
-		"proofpoint",
-		"cloudmark",
-		"banned",
-		"blocked",
-		"denied"):
+	case errors.Is(err, errNullMX):
+		return newLookupError(ErrNoSuchHost, errStr)
+	case insContains(errStr, blockedPhrases.load()...):
 		return newLookupError(ErrBlocked, errStr)
-	case insContains(errStr, "timeout"):
+	case insContains(errStr, "timeout"), errors.Is(err, context.DeadlineExceeded):
 		return newLookupError(ErrTimeout, errStr)
 	case insContains(errStr, "no such host"):
 		return newLookupError(ErrNoSuchHost, errStr)
@@ -0,0 +1,108 @@
+package emailverifier
+
+import "sync/atomic"
+
+// phraseList is a list of reply phrases matched case-insensitively by ParseSMTPError,
+// it can be replaced while replies are parsed on other goroutines
+type phraseList struct {
+	defaults []string
+	current  atomic.Pointer[[]string]
+}
+
+// newPhraseList returns a phraseList holding defaults
+func newPhraseList(defaults ...string) *phraseList {
+	l := &phraseList{defaults: defaults}
+	l.current.Store(&l.defaults)
+	return l
+}
+
+// load returns the current phrases, the slice must not be modified
+func (l *phraseList) load() []string {
+	return *l.current.Load()
+}
+
+// set replaces the phrases, no phrases restore the defaults
+func (l *phraseList) set(phrases []string) {
+	if len(phrases) == 0 {
+		l.current.Store(&l.defaults)
+		return
+	}
+	phrases = append([]string(nil), phrases...)
+	l.current.Store(&phrases)
+}
+
+// copy returns a copy of the current phrases
+func (l *phraseList) copy() []string {
+	return append([]string(nil), l.load()...)
+}
+
+var (
+	// mailboxNotFoundPhrases mark a 5xx reply as ErrMailboxNotFound, these errors
+	// indicate the address doesn't exist, not a server problem
+	mailboxNotFoundPhrases = newPhraseList(
+		"undeliverable",
+		"does not exist",
+		"may not exist",
+		"user unknown",
+		"user not found",
+		"invalid address",
+		"recipient invalid",
+		"recipient rejected",
+		"address rejected",
+		"no mailbox",
+		"no mail-enabled",
+	)
+
+	// blockedPhrases mark a 550 reply or an error without reply code as ErrBlocked
+	blockedPhrases = newPhraseList(
+		"spamhaus",
+		"proofpoint",
+		"cloudmark",
+		"banned",
+		"blacklisted",
+		"blocked",
+		"block list",
+		"denied",
+	)
+
+	// greylistPhrases mark a 4xx reply as ErrTryAgainLater
+	greylistPhrases = newPhraseList(
+		"greylist",
+	)
+)
+
+// SetMailboxNotFoundPhrases replaces the phrases which classify a 5xx reply as
+// ErrMailboxNotFound, e.g. to add the "addressee unknown" of a regional provider
+// to MailboxNotFoundPhrases(). Phrases are matched case-insensitively anywhere
+// in the reply, no phrases restore the defaults. It is safe to call while
+// replies are parsed.
+func SetMailboxNotFoundPhrases(phrases ...string) {
+	mailboxNotFoundPhrases.set(phrases)
+}
+
+// MailboxNotFoundPhrases returns a copy of the phrases set by SetMailboxNotFoundPhrases
+func MailboxNotFoundPhrases() []string {
+	return mailboxNotFoundPhrases.copy()
+}
+
+// SetBlockedPhrases replaces the phrases which classify a 550 reply, or an error
+// without reply code, as ErrBlocked. See SetMailboxNotFoundPhrases for the matching.
+func SetBlockedPhrases(phrases ...string) {
+	blockedPhrases.set(phrases)
+}
+
+// BlockedPhrases returns a copy of the phrases set by SetBlockedPhrases
+func BlockedPhrases() []string {
+	return blockedPhrases.copy()
+}
+
+// SetGreylistPhrases replaces the phrases which classify a 4xx reply as
+// ErrTryAgainLater. See SetMailboxNotFoundPhrases for the matching.
+func SetGreylistPhrases(phrases ...string) {
+	greylistPhrases.set(phrases)
+}
+
+// GreylistPhrases returns a copy of the phrases set by SetGreylistPhrases
+func GreylistPhrases() []string {
+	return greylistPhrases.copy()
+}
@@ -3,6 +3,10 @@ package emailverifier
 import (
 	"errors"
 	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -66,7 +70,7 @@ func TestParseError_Code400_Nil(t *testing.T) {
 	err := errors.New(errStr)
 	le := ParseSMTPError(err)
 
-	assert.Equal(t, &LookupError{Details: errStr, Message: errStr}, le)
+	assert.Equal(t, &LookupError{Details: errStr, Message: errStr, Temporary: true}, le)
 }
 
 func TestParseError_Code401(t *testing.T) {
@@ -74,7 +78,7 @@ func TestParseError_Code401(t *testing.T) {
 	err := errors.New(errStr)
 	le := ParseSMTPError(err)
 
-	assert.Equal(t, &LookupError{Details: errStr, Message: errStr}, le)
+	assert.Equal(t, &LookupError{Details: errStr, Message: errStr, Temporary: true}, le)
 }
 
 func TestParseError_Code421(t *testing.T) {
@@ -274,6 +278,35 @@ func TestParseError_basicErr_connectionReset(t *testing.T) {
 	assert.Equal(t, err.Error(), le.Details)
 }
 
+func TestParse552SizeError(t *testing.T) {
+	le := ParseSMTPError(errors.New("552 Message size exceeds fixed limit"))
+	assert.Equal(t, ErrMessageTooLarge, le.Message)
+	assert.False(t, le.Retryable())
+
+	le = ParseSMTPError(errors.New("552 Requested mail action aborted: exceeded storage allocation"))
+	assert.Equal(t, ErrFullInbox, le.Message)
+}
+
+func TestParseError_connectionResetStage(t *testing.T) {
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+	le := ParseSMTPError(withStage(SMTPStageRCPT, reset))
+	assert.Equal(t, ErrConnectionDropped, le.Message)
+	assert.Equal(t, SMTPStageRCPT, le.Stage)
+	assert.Equal(t, reset.Error(), le.Details)
+	assert.True(t, le.Retryable())
+
+	le = ParseSMTPError(withStage(SMTPStageEHLO, reset))
+	assert.Equal(t, ErrServerUnavailable, le.Message)
+	assert.Equal(t, SMTPStageEHLO, le.Stage)
+
+	// replies and other errors keep their classification without a stage
+	le = ParseSMTPError(withStage(SMTPStageRCPT, &textproto.Error{Code: 550, Msg: "5.1.1 user unknown"}))
+	assert.Equal(t, ErrMailboxNotFound, le.Message)
+	assert.Empty(t, le.Stage)
+	assert.Empty(t, ParseSMTPError(reset).Stage)
+}
+
 // Sometimes a server with disconnect immediately after accepting the
 // connection. This is greylisting in action - a temporary rejection that
 // should be retried (ErrServerUnavailable)
@@ -325,3 +358,206 @@ func TestParseError_550_TLSVersion(t *testing.T) {
 	assert.Equal(t, ErrTLSVersion, le.Message)
 	assert.Equal(t, err.Error(), le.Details)
 }
+
+func TestParseError_550_MailboxDisabled(t *testing.T) {
+	errStr := "550 5.2.1 The email account that you tried to reach is disabled: account disabled"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrMailboxDisabled, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+}
+
+func TestParseError_550_OverQuota(t *testing.T) {
+	errStr := "550 5.2.2 Mailbox over quota"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrFullInbox, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+}
+
+func TestParseError_550_RelayDenied(t *testing.T) {
+	errStr := "550 5.7.1 Relaying denied"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrNoRelay, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+}
+
+func TestParseError_550_BlockedStillBlocked(t *testing.T) {
+	errStr := "550 5.7.1 Service unavailable; client host blocked using Spamhaus"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrBlocked, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+}
+
+func TestEnhancedStatusCode(t *testing.T) {
+	cases := map[string]string{
+		"550 5.1.1 <user@example.com>: user unknown": "5.1.1",
+		"452-4.2.2 The email account is over quota":  "4.2.2",
+		"550 5.7.26 Unauthenticated email":           "5.7.26",
+		"550 5.1.10 RESOLVER.ADR.RecipientNotFound":  "5.1.10",
+		"550 4.1.1 class mismatch":                   "",
+		"550 mailbox unavailable":                    "",
+		"550 5.1.1.2 not an enhanced code":           "",
+		"5.1.1 no reply code":                        "",
+	}
+	for reply, expected := range cases {
+		assert.Equal(t, expected, EnhancedStatusCode(reply), reply)
+	}
+}
+
+// The enhanced status code takes precedence over the text of the reply
+func TestParseError_EnhancedStatusCodePrecedence(t *testing.T) {
+	errStr := "550 5.2.2 Message rejected, blocked"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrFullInbox, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+
+	errStr = "554 5.1.1 Recipient address rejected: access denied"
+	le = ParseSMTPError(errors.New(errStr))
+	assert.Equal(t, ErrMailboxNotFound, le.Message)
+}
+
+// Unmapped enhanced status codes fall back to the text and reply code
+func TestParseError_EnhancedStatusCodeFallback(t *testing.T) {
+	errStr := "554 5.7.1 <email@example.com>: Relay access denied"
+	le := ParseSMTPError(errors.New(errStr))
+	assert.Equal(t, ErrNoRelay, le.Message)
+
+	errStr = "450 4.1.1 <user@example.com>: user unknown"
+	le = ParseSMTPError(errors.New(errStr))
+	assert.Equal(t, ErrMailboxBusy, le.Message)
+}
+
+func TestParseError_MultilineLF(t *testing.T) {
+	errStr := "550-Requested action not taken\n550 5.2.1 account inactive"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrMailboxDisabled, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+	assert.False(t, le.Temporary)
+}
+
+func TestParseError_MultilineCRLF(t *testing.T) {
+	errStr := "452-Requested action not taken\r\n452 4.2.2 try again later"
+	err := errors.New(errStr)
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrFullInbox, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+	assert.True(t, le.Temporary)
+}
+
+// The status code is read from the final line, the keywords of every line are matched
+func TestParseError_MultilineFinalLine(t *testing.T) {
+	le := ParseSMTPError(errors.New("250-first line\r\n550 5.7.1 rejected"))
+	assert.Equal(t, ErrMailboxNotFound, le.Message)
+
+	le = ParseSMTPError(errors.New("550-Your IP is listed by Spamhaus\n550 Requested action not taken"))
+	assert.Equal(t, ErrBlocked, le.Message)
+}
+
+func TestParseError_MultilineTextproto(t *testing.T) {
+	err := &textproto.Error{Code: 550, Msg: "Requested action not taken\n5.1.1 user unknown"}
+	le := ParseSMTPError(err)
+
+	assert.Equal(t, ErrMailboxNotFound, le.Message)
+	assert.Equal(t, err.Error(), le.Details)
+
+	le = ParseSMTPError(&textproto.Error{Code: 550, Msg: "Requested action not taken\n5.2.2 over the limit"})
+	assert.Equal(t, ErrFullInbox, le.Message)
+}
+
+func TestParseError_EnhancedStatusCodeExtended(t *testing.T) {
+	EnhancedStatusCodes["5.7.27"] = ErrBlocked
+	defer delete(EnhancedStatusCodes, "5.7.27")
+
+	le := ParseSMTPError(errors.New("550 5.7.27 Sender address has null MX"))
+	assert.Equal(t, ErrBlocked, le.Message)
+}
+
+func TestParseError_Retryable(t *testing.T) {
+	cases := []struct {
+		errStr    string
+		message   string
+		retryable bool
+	}{
+		{"421 Service not available", ErrTryAgainLater, true},
+		{"450 4.7.1 Recipient address rejected: Greylisted", ErrTryAgainLater, true},
+		{"450 Mailbox busy", ErrMailboxBusy, true},
+		{"451 Too many messages", ErrExceededMessagingLimits, true},
+		{"452 Mailbox full", ErrFullInbox, true},
+		{"452 Too many recipients", ErrTooManyRCPT, true},
+		{"Timeout connecting to mail-exchanger", ErrTimeout, true},
+		{"connection reset by peer", ErrServerUnavailable, true},
+		{"550 5.1.1 user unknown", ErrMailboxNotFound, false},
+		{"550 spamhaus", ErrBlocked, false},
+		{"550 5.2.1 mailbox disabled", ErrMailboxDisabled, false},
+		{"550 5.7.4 TLS version not supported", ErrTLSVersion, false},
+		{"551 User not local", ErrRCPTHasMoved, false},
+		{"552 Mailbox full", ErrFullInbox, false},
+		{"553 Relaying denied", ErrNoRelay, false},
+		{"554 Transaction failed", ErrNotAllowed, false},
+		{"503 Need MAIL before RCPT", ErrNeedMAILBeforeRCPT, false},
+		{"dial tcp: lookup mx.example.com: no such host", ErrNoSuchHost, false},
+	}
+	for _, c := range cases {
+		le := ParseSMTPError(errors.New(c.errStr))
+		assert.Equal(t, c.message, le.Message, c.errStr)
+		assert.Equal(t, c.retryable, le.Retryable(), c.errStr)
+		assert.Equal(t, c.retryable, le.Temporary, c.errStr)
+	}
+
+	var le *LookupError
+	assert.False(t, le.Retryable())
+}
+
+func TestParseError_FullInboxTemporaryOrPermanent(t *testing.T) {
+	cases := []struct {
+		errStr    string
+		temporary bool
+	}{
+		{"452-4.2.2 The email account that you tried to reach is over quota and inactive", true},
+		{"452 4.2.2 Mailbox full, try again later", true},
+		{"452 Insufficient system storage", true},
+		{"552 5.2.2 Mailbox over quota", false},
+		{"552 Requested mail action aborted: exceeded storage allocation", false},
+		{"550 5.2.2 quota exceeded", false},
+	}
+	for _, c := range cases {
+		le := ParseSMTPError(errors.New(c.errStr))
+		assert.Equal(t, ErrFullInbox, le.Message, c.errStr)
+		assert.Equal(t, c.temporary, le.Temporary, c.errStr)
+	}
+}
+
+func TestParseError_CustomPhrases(t *testing.T) {
+	defer SetMailboxNotFoundPhrases()
+	defer SetBlockedPhrases()
+	defer SetGreylistPhrases()
+
+	assert.Equal(t, ErrNotAllowed, ParseSMTPError(errors.New("554 addressee unknown")).Message)
+	SetMailboxNotFoundPhrases(append(MailboxNotFoundPhrases(), "addressee unknown")...)
+	assert.Equal(t, ErrMailboxNotFound, ParseSMTPError(errors.New("554 Addressee Unknown")).Message)
+	assert.Equal(t, ErrMailboxNotFound, ParseSMTPError(errors.New("550 user unknown")).Message)
+
+	SetBlockedPhrases("listed by our filter")
+	assert.Equal(t, ErrBlocked, ParseSMTPError(errors.New("550 your IP is listed by our filter")).Message)
+	assert.Equal(t, ErrMailboxNotFound, ParseSMTPError(errors.New("550 spamhaus")).Message)
+
+	SetGreylistPhrases("come back later")
+	assert.Equal(t, ErrTryAgainLater, ParseSMTPError(errors.New("450 please come back later")).Message)
+
+	// no phrases restore the defaults
+	SetBlockedPhrases()
+	assert.Equal(t, ErrBlocked, ParseSMTPError(errors.New("550 spamhaus")).Message)
+	assert.Contains(t, BlockedPhrases(), "spamhaus")
+}
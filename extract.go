@@ -0,0 +1,59 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ErrNoEmailFound is returned by VerifyFirst when the text contains no valid address
+var ErrNoEmailFound = errors.New("no email address found")
+
+// emailPattern matches the addresses embedded in free text: a dot-atom local part and a
+// domain of at least two labels. Non-ASCII letters and digits are allowed as in RFC 6531,
+// so the punctuation around an address, e.g. a full stop or a CJK colon, isn't matched.
+// The local part doesn't start with a quote, which is more likely to surround the address.
+var emailPattern = regexp.MustCompile(
+	"[a-zA-Z0-9!#$%&*+/=?^_{|}~\\p{L}\\p{N}-][a-zA-Z0-9!#$%&'*+/=?^_`{|}~\\p{L}\\p{N}-]*" +
+		"(?:\\.[a-zA-Z0-9!#$%&'*+/=?^_`{|}~\\p{L}\\p{N}-]+)*" +
+		"@(?:[a-zA-Z0-9\\p{L}\\p{N}](?:[a-zA-Z0-9\\p{L}\\p{N}-]*[a-zA-Z0-9\\p{L}\\p{N}])?\\.)+" +
+		"[a-zA-Z\\p{L}](?:[a-zA-Z0-9\\p{L}\\p{N}-]*[a-zA-Z0-9\\p{L}\\p{N}])?",
+)
+
+// ExtractEmails returns the addresses found in text, e.g. a free-text field, in order of
+// appearance and without duplicates. Addresses are found in "mailto:" links, between angle
+// brackets and followed by punctuation, e.g. "email: a@b.com." yields "a@b.com". Quoted
+// local parts aren't extracted, and the matches failing IsAddressValid are dropped. The
+// addresses are returned cleaned up by NormalizeEmail.
+func ExtractEmails(text string) []string {
+	var emails []string
+	seen := map[string]bool{}
+	for _, loc := range emailPattern.FindAllStringIndex(text, -1) {
+		// the tail of an invalid address, e.g. "b@c.com" of "a..b@c.com" or "a@b@c.com"
+		if start := loc[0]; start > 0 && (text[start-1] == '.' || text[start-1] == '@') {
+			continue
+		}
+		email := NormalizeEmail(text[loc[0]:loc[1]])
+		if seen[email] || !IsAddressValid(email) {
+			continue
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+// VerifyFirst verifies the first address ExtractEmails finds in text like Verify,
+// ErrNoEmailFound is returned when there is none
+func (v *Verifier) VerifyFirst(text string) (*Result, error) {
+	return v.VerifyFirstContext(context.Background(), text)
+}
+
+// VerifyFirstContext is VerifyFirst bound to ctx like VerifyContext
+func (v *Verifier) VerifyFirstContext(ctx context.Context, text string) (*Result, error) {
+	emails := ExtractEmails(text)
+	if len(emails) == 0 {
+		return nil, ErrNoEmailFound
+	}
+	return v.VerifyContext(ctx, emails[0])
+}
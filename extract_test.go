@@ -0,0 +1,40 @@
+package emailverifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractEmails(t *testing.T) {
+	cases := []struct {
+		text   string
+		emails []string
+	}{
+		{"email: a@b.com.", []string{"a@b.com"}},
+		{"write to mailto:support@example.com?subject=hi", []string{"support@example.com"}},
+		{"John Doe <John.Doe@Example.COM>, jane@example.org;", []string{"John.Doe@example.com", "jane@example.org"}},
+		{"(first.last+tag@sub.example.co.uk), or 'quoted@example.com'!", []string{"first.last+tag@sub.example.co.uk", "quoted@example.com"}},
+		{"邮箱：abc@доменное.com。", []string{"abc@доменное.com"}},
+		{"a@b.com and A@B.COM and a@b.com", []string{"a@b.com", "A@b.com"}},
+		{"no address here, nor user@localhost or @example.com", nil},
+		{"a..b@example.com a@b@example.com user@example.123", nil},
+		{"", nil},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.emails, ExtractEmails(c.text), c.text)
+	}
+}
+
+func TestVerifyFirst(t *testing.T) {
+	defer useFakeMX()()
+
+	ret, err := NewVerifier().VerifyFirst("Reach me at <user@example.com> or at other@example.com.")
+	assert.NoError(t, err)
+	assert.Equal(t, "user@example.com", ret.Email)
+	assert.True(t, ret.Syntax.Valid)
+
+	ret, err = NewVerifier().VerifyFirst("no address here")
+	assert.ErrorIs(t, err, ErrNoEmailFound)
+	assert.Nil(t, ret)
+}
@@ -0,0 +1,56 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// errInvalidIP is returned by CheckFCrDNS when the IP is nil or malformed
+var errInvalidIP = errors.New("invalid IP address")
+
+// CheckFCrDNS checks that ip has forward-confirmed reverse DNS: one of its PTR names
+// resolves back to ip through its A/AAAA records. It is meant to diagnose the sending
+// setup, i.e. the IP the verifier connects from, as many providers block connections
+// from IPs without it. The PTR name is returned for logging, the confirmed one when
+// ip has several, empty when ip has none. A missing PTR or A/AAAA record isn't an
+// error, it fails the check.
+func CheckFCrDNS(ip net.IP) (bool, string, error) {
+	if ip == nil || ip.To16() == nil {
+		return false, "", errInvalidIP
+	}
+	ctx := context.Background()
+
+	names, err := lookupAddrContext(ctx, ip.String())
+	if isNotFound(err) || (err == nil && len(names) == 0) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	var lastErr error
+	for _, name := range names {
+		name = strings.TrimSuffix(name, ".")
+		addrs, err := lookupHostContext(ctx, name)
+		if err != nil {
+			if !isNotFound(err) {
+				lastErr = err
+			}
+			continue
+		}
+		for _, addr := range addrs {
+			if ip.Equal(net.ParseIP(addr)) {
+				return true, name, nil
+			}
+		}
+	}
+	return false, strings.TrimSuffix(names[0], "."), lastErr
+}
+
+// isNotFound tells whether err is a DNS error for a name without records
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
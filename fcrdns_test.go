@@ -0,0 +1,67 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFCrDNS(t *testing.T) {
+	originalLookupAddr := lookupAddrContext
+	originalLookupHost := lookupHostContext
+	defer func() {
+		lookupAddrContext = originalLookupAddr
+		lookupHostContext = originalLookupHost
+	}()
+
+	ptr := map[string][]string{
+		"192.0.2.1":   {"mail.example.com."},
+		"192.0.2.2":   {"other.example.com."},
+		"2001:db8::1": {"stale.example.com.", "mail6.example.com."},
+	}
+	hosts := map[string][]string{
+		"mail.example.com":  {"192.0.2.1"},
+		"other.example.com": {"198.51.100.7"},
+		"mail6.example.com": {"2001:db8::1"},
+	}
+	lookupAddrContext = func(ctx context.Context, addr string) ([]string, error) {
+		if names, ok := ptr[addr]; ok {
+			return names, nil
+		}
+		if addr == "192.0.2.9" {
+			return nil, errors.New("server misbehaving")
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		if addrs, ok := hosts[host]; ok {
+			return addrs, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	cases := []struct {
+		ip      string
+		ok      bool
+		name    string
+		wantErr bool
+	}{
+		{"192.0.2.1", true, "mail.example.com", false},
+		{"192.0.2.2", false, "other.example.com", false},
+		{"2001:db8::1", true, "mail6.example.com", false},
+		{"192.0.2.3", false, "", false},
+		{"192.0.2.9", false, "", true},
+	}
+	for _, c := range cases {
+		ok, name, err := CheckFCrDNS(net.ParseIP(c.ip))
+		assert.Equal(t, c.ok, ok, c.ip)
+		assert.Equal(t, c.name, name, c.ip)
+		assert.Equal(t, c.wantErr, err != nil, c.ip)
+	}
+
+	_, _, err := CheckFCrDNS(nil)
+	assert.Equal(t, errInvalidIP, err)
+}
@@ -7,6 +7,7 @@ require (
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/net v0.29.0
+	golang.org/x/time v0.6.0
 	gopkg.in/h2non/gock.v1 v1.1.2
 )
 
@@ -16,7 +16,13 @@ type Gravatar struct {
 
 // CheckGravatar will return the Gravatar records for the given email.
 func (v *Verifier) CheckGravatar(email string) (*Gravatar, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	v = v.snapshot()
+	return v.checkGravatar(context.Background(), email)
+}
+
+// checkGravatar is CheckGravatar bound to ctx
+func (v *Verifier) checkGravatar(ctx context.Context, email string) (*Gravatar, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	err, emailMd5 := getMD5Hash(strings.ToLower(strings.TrimSpace(email)))
 	if err != nil {
@@ -43,27 +43,18 @@ func updateDisposableDomains(source string) error {
 		return err
 	}
 
-	newDomains := make(map[string]struct{})
-	for _, v := range domains {
-		newDomains[v] = struct{}{}
+	// an empty list would wipe out all disposable domains, keep the previous one instead
+	if len(domains) == 0 {
+		return nil
 	}
 
-	// clear up invalid disposable domains
-	disposableSyncDomains.Range(func(key, value interface{}) bool {
-		if _, exists := newDomains[key.(string)]; !exists {
-			disposableSyncDomains.Delete(key)
-		}
-		return true
-	})
-
-	// update new domain data
-	for _, d := range domains {
-		disposableSyncDomains.Store(d, struct{}{})
+	newDomains := make(map[string]struct{}, len(domains))
+	for _, v := range domains {
+		newDomains[v] = struct{}{}
 	}
 
-	// add additionalDisposableDomains again
-	for d := range additionalDisposableDomains {
-		disposableSyncDomains.Store(d, struct{}{})
-	}
+	// swap the whole list at once so lookups never see a partially updated list,
+	// domains added via AddDisposableDomains are kept
+	disposableDomainSet.replace(newDomains)
 	return nil
 }
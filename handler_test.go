@@ -78,3 +78,16 @@ func TestUpdateDisposableDomains_WrongResponse(t *testing.T) {
 	err := updateDisposableDomains(disposableDataURL)
 	assert.Error(t, err, "invalid character 'e' in literal true (expecting 'r')")
 }
+
+func TestUpdateDisposableDomains_EmptyListKeepsPrevious(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://raw.githubusercontent.com").
+		Get("/disposable/disposable-email-domains/master/domains.json").
+		Reply(http.StatusOK).
+		JSON([]string{})
+
+	before := verifier.IsDisposable("zzjbfwqi.shop")
+	err := updateDisposableDomains(disposableDataURL)
+	assert.NoError(t, err)
+	assert.Equal(t, before, verifier.IsDisposable("zzjbfwqi.shop"))
+}
@@ -0,0 +1,94 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// IPPreference selects the IP families used to connect to the MX hosts
+type IPPreference int
+
+const (
+	// IPAuto connects over IPv4 or IPv6 as resolved by the system. This is the default.
+	IPAuto IPPreference = iota
+	// IPv4Only connects over IPv4 only, hosts without an A record fail to connect
+	IPv4Only
+	// IPv6Only connects over IPv6 only, hosts without an AAAA record fail to connect
+	IPv6Only
+	// IPv4First connects over IPv4 and falls back to IPv6 when the host has no
+	// reachable IPv4 address
+	IPv4First
+)
+
+// String returns the name of the preference
+func (p IPPreference) String() string {
+	switch p {
+	case IPv4Only:
+		return "ipv4-only"
+	case IPv6Only:
+		return "ipv6-only"
+	case IPv4First:
+		return "ipv4-first"
+	default:
+		return "auto"
+	}
+}
+
+// networks returns the networks dialed in order for the preference
+func (p IPPreference) networks() []string {
+	switch p {
+	case IPv4Only:
+		return []string{"tcp4"}
+	case IPv6Only:
+		return []string{"tcp6"}
+	case IPv4First:
+		return []string{"tcp4", "tcp6"}
+	default:
+		return []string{"tcp"}
+	}
+}
+
+// dialPreferred connects to addr over the networks of pref in order, the error
+// of the last network is returned when none connects. The networks share timeout.
+func dialPreferred(ctx context.Context, addr string, timeout time.Duration, pref IPPreference) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	if timeout > 0 {
+		dialer.Deadline = time.Now().Add(timeout)
+	}
+	var lastErr error
+	for _, network := range pref.networks() {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// ipFamily returns "IPv4" or "IPv6" for the IP of addr, empty when addr has no IP
+func ipFamily(addr net.Addr) string {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return ""
+		}
+		ip = net.ParseIP(host)
+	}
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return "IPv4"
+	default:
+		return "IPv6"
+	}
+}
@@ -0,0 +1,76 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listenGreeting accepts TCP connections on 127.0.0.1 and greets them
+func listenGreeting(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("220 fake.example.com ESMTP\r\n"))
+			_ = conn.Close()
+		}
+	}()
+	return ln
+}
+
+func TestDialPreferred(t *testing.T) {
+	ln := listenGreeting(t)
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	for _, pref := range []IPPreference{IPAuto, IPv4Only, IPv4First} {
+		conn, err := dialPreferred(context.Background(), addr, time.Second, pref)
+		if assert.NoError(t, err, pref.String()) {
+			assert.Equal(t, "IPv4", ipFamily(conn.RemoteAddr()))
+			_ = conn.Close()
+		}
+	}
+
+	_, err := dialPreferred(context.Background(), addr, time.Second, IPv6Only)
+	assert.Error(t, err)
+}
+
+func TestDialSMTP_ReportsIPFamily(t *testing.T) {
+	ln := listenGreeting(t)
+	defer ln.Close()
+
+	tr := &transcript{}
+	client, err := dialSMTP(ln.Addr().String(), dialOptions{
+		connectTimeout:   time.Second,
+		operationTimeout: time.Second,
+		transcript:       tr,
+		ipPreference:     IPv4Only,
+	})
+	if assert.NoError(t, err) {
+		_ = client.Close()
+	}
+	lines := tr.linesOf("127.0.0.1")
+	if assert.NotEmpty(t, lines) {
+		assert.Equal(t, "* connected to "+ln.Addr().String()+" over IPv4", lines[0])
+	}
+}
+
+func TestIPFamily(t *testing.T) {
+	assert.Equal(t, "IPv4", ipFamily(&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 25}))
+	assert.Equal(t, "IPv6", ipFamily(&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 25}))
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	assert.Equal(t, "", ipFamily(client.RemoteAddr()))
+}
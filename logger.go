@@ -0,0 +1,34 @@
+package emailverifier
+
+import "net/url"
+
+// Logger receives the log entries of the verifier, such as fallbacks, retries and
+// proxy rotation. keysAndValues are alternating keys and values, e.g. "host", "mx.example.com",
+// which maps directly to slog, zap's SugaredLogger or logrus fields.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+}
+
+// NopLogger is a Logger which discards all entries
+type NopLogger struct{}
+
+// Debug implements Logger
+func (NopLogger) Debug(string, ...any) {}
+
+// Info implements Logger
+func (NopLogger) Info(string, ...any) {}
+
+// Warn implements Logger
+func (NopLogger) Warn(string, ...any) {}
+
+// redactProxyURI hides the password of proxyURI so that it can be logged
+func redactProxyURI(proxyURI string) string {
+	u, err := url.Parse(proxyURI)
+	if err != nil {
+		return "<invalid proxy>"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,41 @@
+package emailverifier
+
+import "context"
+
+// MailboxChecker checks the existence of a mailbox without SMTP, e.g. through the API
+// of a data partner, see RegisterMailboxChecker. CheckMailbox returns the result of the
+// mailbox like the SMTP check, an error is best returned as a *LookupError so the
+// result is classified like the errors of the SMTP check. A checker may pass a domain
+// on to the next backend by returning a nil SMTP and a nil error.
+type MailboxChecker interface {
+	CheckMailbox(ctx context.Context, domain, username string) (*SMTP, error)
+}
+
+// MailboxCheckerFunc is a function implementing MailboxChecker
+type MailboxCheckerFunc func(ctx context.Context, domain, username string) (*SMTP, error)
+
+// CheckMailbox implements MailboxChecker
+func (f MailboxCheckerFunc) CheckMailbox(ctx context.Context, domain, username string) (*SMTP, error) {
+	return f(ctx, domain, username)
+}
+
+// checkMailboxWithoutSMTP checks the mailbox with the backends registered for domain,
+// in order its MailboxChecker, the API verifiers and the fallback MailboxChecker, and
+// returns the MailboxBackend which handled it. A nil SMTP and error mean no backend
+// handled the domain, so it must be checked by SMTP.
+func (v *Verifier) checkMailboxWithoutSMTP(ctx context.Context, domain, username string) (*SMTP, string, error) {
+	if checker := v.mailboxCheckers[cleanDomain(domain)]; checker != nil {
+		if ret, err := checker.CheckMailbox(ctx, domain, username); ret != nil || err != nil {
+			return ret, MailboxBackendChecker, err
+		}
+	}
+	if apiVerifier := v.apiVerifierFor(ctx, domain); apiVerifier != nil {
+		ret, err := apiVerifier.check(ctx, domain, username, v.apiOptions())
+		return ret, MailboxBackendAPI, err
+	}
+	if v.mailboxFallback != nil {
+		ret, err := v.mailboxFallback.CheckMailbox(ctx, domain, username)
+		return ret, MailboxBackendChecker, err
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,81 @@
+package emailverifier
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSMTP_MailboxChecker(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	partner := MailboxCheckerFunc(func(ctx context.Context, domain, username string) (*SMTP, error) {
+		return &SMTP{HostExists: true, Deliverable: username == "user"}, nil
+	})
+	v := NewVerifier().EnableSMTPCheck().RegisterMailboxChecker(partner, "Partner.example.com")
+
+	ret, err := v.CheckSMTP("partner.example.com", "user")
+	assert.NoError(t, err)
+	assert.True(t, ret.Deliverable)
+	assert.Equal(t, CatchAllUnknown, ret.CatchAllStatus)
+	assert.Zero(t, atomic.LoadInt32(dials))
+
+	// other domains are still checked by SMTP
+	ret, err = v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.True(t, ret.Deliverable)
+	assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	// MX-only mode doesn't check the mailbox
+	ret, err = v.EnableMXOnlyMode().CheckSMTP("partner.example.com", "user")
+	assert.NoError(t, err)
+	assert.True(t, ret.MailboxCheckSkipped)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+
+	_, err = v.DisableMXOnlyMode().RegisterMailboxChecker(nil, "partner.example.com").CheckSMTP("partner.example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(dials))
+}
+
+func TestCheckSMTP_MailboxCheckerFallback(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	var calls []string
+	pass := MailboxCheckerFunc(func(ctx context.Context, domain, username string) (*SMTP, error) {
+		calls = append(calls, "pass")
+		return nil, nil
+	})
+	fallback := MailboxCheckerFunc(func(ctx context.Context, domain, username string) (*SMTP, error) {
+		calls = append(calls, "fallback")
+		if domain == "smtp.example.com" {
+			return nil, nil
+		}
+		return nil, &LookupError{Message: ErrTryAgainLater, Details: "partner quota exceeded", Temporary: true}
+	})
+	v := NewVerifier().EnableSMTPCheck().RegisterMailboxChecker(pass, "example.com").RegisterMailboxChecker(fallback)
+
+	ret, err := v.CheckSMTP("example.com", "user")
+	assert.Nil(t, ret)
+	assert.ErrorContains(t, err, "partner quota exceeded")
+	assert.Equal(t, []string{"pass", "fallback"}, calls)
+	assert.Zero(t, atomic.LoadInt32(dials))
+
+	// a domain passed on by all checkers is checked by SMTP
+	ret, err = v.CheckSMTP("smtp.example.com", "user")
+	assert.NoError(t, err)
+	assert.True(t, ret.Deliverable)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	// CheckDomain probes the catch-all address by SMTP
+	defer useFakeMX()()
+	calls = nil
+	domain, err := v.CheckDomain("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchAllNo, domain.CatchAllStatus)
+	assert.Empty(t, calls)
+}
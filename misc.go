@@ -1,27 +1,67 @@
 package emailverifier
 
 import (
+	"context"
 	"strings"
-	"sync"
+	"sync/atomic"
 )
 
 var (
-	disposableSyncDomains sync.Map // concurrent safe map to store disposable domains data
+	disposableDomainSet = newDomainSet(disposableDomains) // concurrent safe set to store disposable domains data
+	roleAccountSet      = newDomainSet(roleAccounts)      // concurrent safe set to store role-based accounts data
+	freeDomainSet       atomic.Pointer[map[string]bool]   // free domains data, swapped as a whole by LoadFreeDomains
 )
 
-// IsRoleAccount checks if username is a role-based account
+// init loads the free domains meta data to freeDomainSet
+func init() {
+	freeDomainSet.Store(&freeDomains)
+}
+
+// loadedFreeDomains returns the current free domains, the map must not be modified
+func loadedFreeDomains() map[string]bool {
+	return *freeDomainSet.Load()
+}
+
+// IsRoleAccount checks if username is a role-based account.
+// The match is case-insensitive and ignores any plus-addressing suffix,
+// so "Support+ticket" is treated as "support".
 func (v *Verifier) IsRoleAccount(username string) bool {
-	return roleAccounts[strings.ToLower(username)]
+	return v.RoleAccountName(username) != ""
+}
+
+// RoleAccountName returns the lowercased role of username, or an empty string
+// when username isn't a role-based account. The plus-addressing suffix is
+// ignored, so "Support+ticket123" returns "support".
+func (v *Verifier) RoleAccountName(username string) string {
+	role := strings.ToLower(stripPlusAddressing(username))
+	if !roleAccountSet.contains(role) {
+		return ""
+	}
+	return role
 }
 
-// IsFreeDomain checks if domain is a free domain
+// IsFreeDomain checks if domain is a free domain, the match is case-insensitive
+// and includes subdomains of free domains (e.g. "mail.gmail.com")
 func (v *Verifier) IsFreeDomain(domain string) bool {
-	return freeDomains[domain]
+	domain = cleanDomain(domain)
+	free := loadedFreeDomains()
+	for ; strings.Contains(domain, "."); domain = parentDomain(domain) {
+		if free[domain] {
+			return true
+		}
+	}
+	return false
 }
 
-// IsDisposable checks if domain is a disposable domain
+// IsDisposable checks if domain is a disposable domain,
+// it is safe to call while domains are added or removed on other goroutines.
+// With EnableDisposableMXHeuristic, a domain missing from the list is also
+// disposable when its MX hosts belong to a disposable provider, which costs
+// an MX lookup.
 func (v *Verifier) IsDisposable(domain string) bool {
-	domain = domainToASCII(domain)
-	_, found := disposableSyncDomains.Load(domain)
-	return found
+	if disposableDomainSet.contains(cleanDomain(domain)) {
+		return true
+	}
+	v = v.snapshot()
+	return v.disposableSource(context.Background(), domain, nil) != ""
 }
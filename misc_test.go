@@ -1,7 +1,12 @@
 package emailverifier
 
 import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -49,3 +54,161 @@ func TestIsRoleAccount_False(t *testing.T) {
 	isRoleAccount := verifier.IsRoleAccount(username)
 	assert.False(t, isRoleAccount)
 }
+
+func TestIsRoleAccount_CaseInsensitive(t *testing.T) {
+	username := "PostMaster"
+
+	isRoleAccount := verifier.IsRoleAccount(username)
+	assert.True(t, isRoleAccount)
+}
+
+func TestIsRoleAccount_PlusAddressing(t *testing.T) {
+	username := "info+newsletter"
+
+	isRoleAccount := verifier.IsRoleAccount(username)
+	assert.True(t, isRoleAccount)
+}
+
+func TestRoleAccountName_PlusAddressing(t *testing.T) {
+	cases := map[string]string{
+		"support+ticket123": "support",
+		"Support+XYZ":       "support",
+		"support+":          "support",
+		"info+a+b":          "info",
+		"support":           "support",
+		"john+support":      "",
+		"+support":          "",
+		"normal_user+tag":   "",
+	}
+	for username, role := range cases {
+		assert.Equal(t, role, verifier.RoleAccountName(username), username)
+		assert.Equal(t, role != "", verifier.IsRoleAccount(username), username)
+	}
+}
+
+func TestAddRoleAccounts(t *testing.T) {
+	username := "Underwriting"
+	assert.False(t, verifier.IsRoleAccount(username))
+
+	verifier.AddRoleAccounts("underwriting")
+	assert.True(t, verifier.IsRoleAccount(username))
+	assert.True(t, verifier.IsRoleAccount(username+"+quotes"))
+}
+
+func TestAddRemoveDisposableDomains(t *testing.T) {
+	domain := "runtime-disposable.test"
+	assert.False(t, verifier.IsDisposable(domain))
+
+	verifier.AddDisposableDomains("Runtime-Disposable.TEST.")
+	assert.True(t, verifier.IsDisposable(domain))
+	assert.True(t, verifier.IsDisposable("RUNTIME-DISPOSABLE.test."))
+
+	verifier.RemoveDisposableDomains(domain)
+	assert.False(t, verifier.IsDisposable(domain))
+}
+
+func TestAddDisposableDomains_Wildcard(t *testing.T) {
+	verifier.AddDisposableDomains("*.wildcard-disposable.test")
+	defer verifier.RemoveDisposableDomains("*.wildcard-disposable.test")
+
+	assert.True(t, verifier.IsDisposable("abc123.wildcard-disposable.test"))
+	assert.False(t, verifier.IsDisposable("wildcard-disposable.test"))
+}
+
+func TestIsDisposable_ConcurrentUpdates(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			verifier.AddDisposableDomains("concurrent-disposable.test")
+			verifier.RemoveDisposableDomains("concurrent-disposable.test")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			verifier.IsDisposable("concurrent-disposable.test")
+		}
+	}()
+	wg.Wait()
+	assert.False(t, verifier.IsDisposable("concurrent-disposable.test"))
+}
+
+func TestIsFreeDomain_CaseInsensitive(t *testing.T) {
+	assert.True(t, verifier.IsFreeDomain("GMail.com"))
+	assert.True(t, verifier.IsFreeDomain("gmail.com."))
+}
+
+func TestIsFreeDomain_SubDomain(t *testing.T) {
+	assert.True(t, verifier.IsFreeDomain("mail.gmail.com"))
+	assert.False(t, verifier.IsFreeDomain("com"))
+	assert.False(t, verifier.IsFreeDomain("mail.github.com"))
+}
+
+// metadataSet converts metadata to the base domains of a domainSet
+func metadataSet(metadata map[string]bool) map[string]struct{} {
+	set := make(map[string]struct{}, len(metadata))
+	for k := range metadata {
+		set[k] = struct{}{}
+	}
+	return set
+}
+
+func TestLoadDisposableDomains(t *testing.T) {
+	defer disposableDomainSet.replace(metadataSet(disposableDomains))
+	verifier.AddDisposableDomains("kept-disposable.test")
+	defer verifier.RemoveDisposableDomains("kept-disposable.test")
+
+	err := verifier.LoadDisposableDomains(strings.NewReader("# pinned list\n\n  Pinned-Disposable.TEST.  \n*.wild-pinned.test\n/^mail[0-9]+\\.rotating-pinned\\.[a-z]+$/\n"))
+	assert.NoError(t, err)
+	assert.True(t, verifier.IsDisposable("pinned-disposable.test"))
+	assert.True(t, verifier.IsDisposable("abc123.wild-pinned.test"))
+	assert.True(t, verifier.IsDisposable("mail42.rotating-pinned.xyz"))
+	assert.False(t, verifier.IsDisposable("mail.rotating-pinned.xyz"))
+	assert.True(t, verifier.IsDisposable("kept-disposable.test"))
+	assert.False(t, verifier.IsDisposable("# pinned list"))
+	assert.False(t, verifier.IsDisposable("mailinator.com"))
+
+	err = verifier.LoadDisposableDomains(iotest.ErrReader(errors.New("read failed")))
+	assert.Error(t, err)
+	assert.True(t, verifier.IsDisposable("pinned-disposable.test"))
+
+	err = verifier.LoadDisposableDomains(strings.NewReader("other-disposable.test\n/mail[0-9+/\n"))
+	assert.ErrorContains(t, err, "invalid regexp entry")
+	assert.True(t, verifier.IsDisposable("pinned-disposable.test"))
+	assert.False(t, verifier.IsDisposable("other-disposable.test"))
+}
+
+func TestAddDisposableRegexps(t *testing.T) {
+	re := regexp.MustCompile(`^[a-z0-9]+\.per-user-disposable\.(com|net)$`)
+	verifier.AddDisposableRegexps(re)
+	assert.True(t, verifier.IsDisposable("abc123.per-user-disposable.net"))
+	assert.True(t, verifier.IsDisposable("ABC123.Per-User-Disposable.com."))
+	assert.False(t, verifier.IsDisposable("per-user-disposable.com"))
+
+	verifier.RemoveDisposableRegexps(regexp.MustCompile(re.String()))
+	assert.False(t, verifier.IsDisposable("abc123.per-user-disposable.net"))
+}
+
+func TestLoadFreeDomains(t *testing.T) {
+	defer freeDomainSet.Store(&freeDomains)
+
+	err := verifier.LoadFreeDomains(strings.NewReader("# pinned list\nPinned-Free.test\n"))
+	assert.NoError(t, err)
+	assert.True(t, verifier.IsFreeDomain("pinned-free.test"))
+	assert.True(t, verifier.IsFreeDomain("mail.pinned-free.test"))
+	assert.False(t, verifier.IsFreeDomain("gmail.com"))
+	assert.Equal(t, "pinned-free.test", verifier.SuggestDomain("pinned-fre.test"))
+}
+
+func TestLoadRoleAccounts(t *testing.T) {
+	defer roleAccountSet.replace(metadataSet(roleAccounts))
+	verifier.AddRoleAccounts("kept-role")
+
+	err := verifier.LoadRoleAccounts(strings.NewReader("# pinned list\n Dispatch \n"))
+	assert.NoError(t, err)
+	assert.True(t, verifier.IsRoleAccount("dispatch"))
+	assert.True(t, verifier.IsRoleAccount("kept-role"))
+	assert.False(t, verifier.IsRoleAccount("info"))
+}
@@ -1,22 +1,111 @@
 package emailverifier
 
-import "net"
+import (
+	"context"
+	"net"
+)
+
+var (
+	lookupMXContext   = net.DefaultResolver.LookupMX
+	lookupHostContext = net.DefaultResolver.LookupHost
+)
 
 // Mx is detail about the Mx host
 type Mx struct {
-	HasMXRecord bool      // whether has 1 or more MX record
-	Records     []*net.MX // represent DNS MX records
+	HasMXRecord bool       // whether has 1 or more MX record
+	ImplicitMX  bool       // whether the domain has no MX record and its A/AAAA record is used as an implicit MX
+	NullMX      bool       // whether the domain publishes a null MX ("0 .", RFC 7505), i.e. it doesn't accept mail
+	Records     []*net.MX  // represent DNS MX records
+	MXRecords   []MXRecord // Records with their TTL, zero unless EnableMXTTL is set
 }
 
 // CheckMX will return the DNS MX records for the given domain name sorted by preference.
+// A domain without MX records but with an A/AAAA record gets the domain itself as
+// an implicit MX with preference 0, as described in RFC 5321 section 5.1, a lookup
+// which failed otherwise than NXDOMAIN or NODATA, e.g. on SERVFAIL, is an error.
+// A domain publishing a null MX (RFC 7505) gets NullMX set and no implicit MX,
+// HasMXRecord is false as it has no mail exchanger.
 func (v *Verifier) CheckMX(domain string) (*Mx, error) {
+	v = v.snapshot()
+	return v.checkMX(context.Background(), domain)
+}
+
+// checkMX is CheckMX bound to ctx
+func (v *Verifier) checkMX(ctx context.Context, domain string) (*Mx, error) {
 	domain = domainToASCII(domain)
-	mx, err := net.LookupMX(domain)
+	mx, records, err := v.lookupMX(ctx, domain)
+	if isNullMX(mx) {
+		return &Mx{
+			NullMX:    true,
+			Records:   mx,
+			MXRecords: records,
+		}, nil
+	}
+	if hasNoMX(mx, err) {
+		if implicit, ok := implicitMX(ctx, domain); ok {
+			v.logger.Info("no MX records, falling back to the A/AAAA record", "domain", domain)
+			return &Mx{
+				ImplicitMX: true,
+				Records:    implicit,
+				MXRecords:  mxRecords(implicit),
+			}, nil
+		}
+	}
 	if err != nil && len(mx) == 0 {
 		return nil, err
 	}
 	return &Mx{
 		HasMXRecord: len(mx) > 0,
 		Records:     mx,
+		MXRecords:   records,
 	}, nil
 }
+
+// lookupMX looks up the MX records of domain, with their TTL when EnableMXTTL is
+// set. A failed TTL lookup falls back to the system resolver, leaving the TTL zero.
+// With MaxCNAMEDepth, a CNAME chain looping or longer than the depth is an error.
+func (v *Verifier) lookupMX(ctx context.Context, domain string) ([]*net.MX, []MXRecord, error) {
+	if v.maxCNAMEDepth > 0 {
+		records, fallback, err := lookupMXWithCNAMEDepth(ctx, domain, v.maxCNAMEDepth, v.logger)
+		if !fallback {
+			if err != nil {
+				return nil, nil, err
+			}
+			if !v.mxTTLEnabled {
+				records = mxRecords(netMX(records))
+			}
+			return netMX(records), records, nil
+		}
+	} else if v.mxTTLEnabled {
+		records, err := lookupMXTTLContext(ctx, domain)
+		if err == nil {
+			return netMX(records), records, nil
+		}
+		v.logger.Debug("MX lookup with TTL failed, falling back to the system resolver", "domain", domain, "error", err)
+	}
+	mx, err := lookupMXContext(ctx, domain)
+	return mx, mxRecords(mx), err
+}
+
+// implicitMX returns the domain itself as an MX record with preference 0
+// when the domain has an A/AAAA record
+func implicitMX(ctx context.Context, domain string) ([]*net.MX, bool) {
+	addrs, err := lookupHostContext(ctx, domain)
+	if err != nil || len(addrs) == 0 {
+		return nil, false
+	}
+	return []*net.MX{{Host: domain + ".", Pref: 0}}, true
+}
+
+// hasNoMX reports whether the MX lookup returning mx and err found that the domain
+// has no MX records (NXDOMAIN or NODATA), which the A/AAAA fallback applies to.
+// A lookup which failed otherwise, e.g. SERVFAIL or a timeout, didn't.
+func hasNoMX(mx []*net.MX, err error) bool {
+	return len(mx) == 0 && (err == nil || isNotFound(err))
+}
+
+// isNullMX reports whether records are the single null MX record "." of RFC 7505,
+// which declares that the domain doesn't accept mail
+func isNullMX(records []*net.MX) bool {
+	return len(records) == 1 && records[0].Host == "."
+}
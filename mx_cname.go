@@ -0,0 +1,125 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// lookupMXChainContext is the MX lookup used when MaxCNAMEDepth is set
+var lookupMXChainContext = lookupMXChain
+
+// CNAMEError is returned by the MX lookup when the CNAME chain of the domain
+// loops or follows more aliases than MaxCNAMEDepth allows
+type CNAMEError struct {
+	Chain    []string // names of the chain in resolution order, starting with the domain
+	Loop     bool     // the last name of Chain already appeared in it
+	MaxDepth int      // number of CNAMEs allowed when the chain is too long
+}
+
+// Error implements error
+func (e *CNAMEError) Error() string {
+	chain := strings.Join(e.Chain, " -> ")
+	if e.Loop {
+		return "CNAME loop: " + chain
+	}
+	return fmt.Sprintf("CNAME chain longer than %d: %s", e.MaxDepth, chain)
+}
+
+// isCNAMEError reports whether err is a *CNAMEError, which the system resolver
+// isn't asked to look up again
+func isCNAMEError(err error) bool {
+	var cnameErr *CNAMEError
+	return errors.As(err, &cnameErr)
+}
+
+// lookupMXChain queries the MX records of domain and their TTL from the system nameservers,
+// following the CNAMEs of the answers through at most maxDepth aliases. A chain stopping at
+// an alias without its MX records is queried again from that alias. The names resolved are
+// returned in order, starting with domain, also on error.
+func lookupMXChain(ctx context.Context, domain string, maxDepth int) ([]MXRecord, []string, error) {
+	current := strings.TrimSuffix(domain, ".") + "."
+	chain := []string{current}
+	for {
+		name, err := dnsmessage.NewName(current)
+		if err != nil {
+			return nil, chain, err
+		}
+		resp, err := queryServers(ctx, func(server string) (*dnsmessage.Message, error) {
+			return exchangeMX(ctx, server, name)
+		})
+		if err != nil {
+			return nil, chain, err
+		}
+
+		followed := false
+		for target := cnameOf(resp, current); target != ""; target = cnameOf(resp, current) {
+			for _, n := range chain {
+				if strings.EqualFold(n, target) {
+					return nil, chain, &CNAMEError{Chain: append(chain, target), Loop: true}
+				}
+			}
+			if len(chain) > maxDepth {
+				return nil, chain, &CNAMEError{Chain: append(chain, target), MaxDepth: maxDepth}
+			}
+			chain = append(chain, target)
+			current = target
+			followed = true
+		}
+
+		if records := mxAnswersOf(resp, current); len(records) > 0 {
+			return records, chain, nil
+		}
+		if !followed {
+			return nil, chain, &net.DNSError{Err: "no such host", Name: strings.TrimSuffix(current, "."), IsNotFound: true}
+		}
+	}
+}
+
+// cnameOf returns the target of the CNAME answer of owner, empty when there is none
+func cnameOf(resp *dnsmessage.Message, owner string) string {
+	for _, answer := range resp.Answers {
+		cname, ok := answer.Body.(*dnsmessage.CNAMEResource)
+		if ok && strings.EqualFold(answer.Header.Name.String(), owner) {
+			return cname.CNAME.String()
+		}
+	}
+	return ""
+}
+
+// mxAnswersOf returns the MX answers of owner sorted by preference
+func mxAnswersOf(resp *dnsmessage.Message, owner string) []MXRecord {
+	var records []MXRecord
+	for _, answer := range resp.Answers {
+		mx, ok := answer.Body.(*dnsmessage.MXResource)
+		if ok && strings.EqualFold(answer.Header.Name.String(), owner) {
+			records = append(records, MXRecord{Host: mx.MX.String(), Pref: mx.Pref, TTL: answer.Header.TTL})
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+	return records
+}
+
+// lookupMXWithCNAMEDepth looks up the MX records of domain following at most maxDepth
+// CNAMEs, the chain of an aliased domain is logged at debug level when logger isn't nil.
+// fallback tells whether the lookup failed on a transport or server error, so the
+// records may still be looked up by the system resolver. A name which doesn't exist or
+// has neither MX records nor a CNAME isn't looked up again.
+func lookupMXWithCNAMEDepth(ctx context.Context, domain string, maxDepth int, logger Logger) (records []MXRecord, fallback bool, err error) {
+	records, chain, err := lookupMXChainContext(ctx, domain, maxDepth)
+	if logger != nil && len(chain) > 1 {
+		logger.Debug("MX lookup followed CNAMEs", "domain", domain, "chain", strings.Join(chain, " -> "))
+	}
+	if err != nil && !isCNAMEError(err) && !isNotFound(err) {
+		if logger != nil {
+			logger.Debug("MX lookup following CNAMEs failed, falling back to the system resolver", "domain", domain, "error", err)
+		}
+		return nil, true, err
+	}
+	return records, false, err
+}
@@ -0,0 +1,109 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMX_CNAMEChain(t *testing.T) {
+	defer useFakeCNAMEServer(t,
+		map[string][]MXRecord{"mail.provider.example.": {{Host: "mx.provider.example.", Pref: 10, TTL: 300}}},
+		map[string]string{"example.com.": "alias.example.net.", "alias.example.net.": "mail.provider.example."},
+	)()
+
+	l := &recordingLogger{}
+	mx, err := NewVerifier().MaxCNAMEDepth(2).WithLogger(l).CheckMX("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []*net.MX{{Host: "mx.provider.example.", Pref: 10}}, mx.Records)
+	// the TTL is only reported with EnableMXTTL
+	assert.Equal(t, []MXRecord{{Host: "mx.provider.example.", Pref: 10}}, mx.MXRecords)
+	assert.True(t, l.contains("DEBUG MX lookup followed CNAMEs [domain example.com chain example.com. -> alias.example.net. -> mail.provider.example.]"))
+}
+
+func TestCheckMX_CNAMEDepthExceeded(t *testing.T) {
+	defer useFakeCNAMEServer(t,
+		map[string][]MXRecord{"mail.provider.example.": {{Host: "mx.provider.example.", Pref: 10}}},
+		map[string]string{"example.com.": "alias.example.net.", "alias.example.net.": "mail.provider.example."},
+	)()
+	defer useResolvableHost()()
+
+	_, err := NewVerifier().MaxCNAMEDepth(1).CheckMX("example.com")
+	var cnameErr *CNAMEError
+	if assert.True(t, errors.As(err, &cnameErr)) {
+		assert.False(t, cnameErr.Loop)
+		assert.Equal(t, []string{"example.com.", "alias.example.net.", "mail.provider.example."}, cnameErr.Chain)
+		assert.EqualError(t, err, "CNAME chain longer than 1: example.com. -> alias.example.net. -> mail.provider.example.")
+	}
+}
+
+// useResolvableHost makes every host resolve, so a lookup error falling back
+// to the implicit MX would go unnoticed
+func useResolvableHost() func() {
+	original := lookupHostContext
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"192.0.2.1"}, nil
+	}
+	return func() { lookupHostContext = original }
+}
+
+func TestCheckMX_CNAMELoop(t *testing.T) {
+	defer useFakeCNAMEServer(t, nil, map[string]string{"example.com.": "a.example.net.", "a.example.net.": "Example.com."})()
+	defer useResolvableHost()()
+
+	_, err := NewVerifier().MaxCNAMEDepth(5).CheckMX("example.com")
+	var cnameErr *CNAMEError
+	if assert.True(t, errors.As(err, &cnameErr)) {
+		assert.True(t, cnameErr.Loop)
+		assert.EqualError(t, err, "CNAME loop: example.com. -> a.example.net. -> Example.com.")
+	}
+}
+
+func TestCheckSMTP_CNAMELoop(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	defer useFakeCNAMEServer(t, nil, map[string]string{"example.com.": "example.com."})()
+	defer useResolvableHost()()
+	dials := countDials()
+
+	_, err := NewVerifier().EnableSMTPCheck().MaxCNAMEDepth(3).CheckSMTP("example.com", "user")
+	var le *LookupError
+	if assert.ErrorAs(t, err, &le) {
+		assert.Equal(t, "CNAME loop: example.com. -> example.com.", le.Message)
+	}
+	assert.Equal(t, int32(0), *dials)
+}
+
+func TestLookupMXChain_NotFound(t *testing.T) {
+	defer useFakeCNAMEServer(t, nil, map[string]string{"example.com.": "gone.example.net."})()
+
+	_, chain, err := lookupMXChain(context.Background(), "example.com", 3)
+	var dnsErr *net.DNSError
+	if assert.True(t, errors.As(err, &dnsErr)) {
+		assert.True(t, dnsErr.IsNotFound)
+	}
+	assert.Equal(t, []string{"example.com.", "gone.example.net."}, chain)
+}
+
+func TestCheckMX_CNAMEDepthNotFound(t *testing.T) {
+	defer useFakeCNAMEServer(t, nil, map[string]string{"example.com.": "gone.example.net."})()
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	var systemLookups int
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		systemLookups++
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	defer useResolvableHost()()
+
+	// a name which doesn't exist isn't looked up again by the system resolver,
+	// the A/AAAA fallback still applies
+	for _, domain := range []string{"example.com", "nxdomain.example.org"} {
+		mx, err := NewVerifier().MaxCNAMEDepth(3).CheckMX(domain)
+		assert.NoError(t, err)
+		assert.True(t, mx.ImplicitMX)
+	}
+	assert.Equal(t, 0, systemLookups)
+}
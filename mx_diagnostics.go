@@ -0,0 +1,58 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+)
+
+var lookupAddrContext = net.DefaultResolver.LookupAddr
+
+// MXDiagnostics is the DNS detail of the MX host of an SMTP check, it helps telling
+// a DNS issue from a dead host and explaining blocks keyed on reverse DNS
+type MXDiagnostics struct {
+	Host string            `json:"host"`          // MX host connected to, or the preferred one when none could be connected
+	IPs  []string          `json:"ips,omitempty"` // A/AAAA records of the host
+	PTR  map[string]string `json:"ptr,omitempty"` // first PTR (reverse DNS) name of each IP, IPs without a PTR are left out
+}
+
+// diagnoseMX looks up the IPs of host and their PTR records,
+// nil is returned when diagnostics are disabled or host is empty
+func (v *Verifier) diagnoseMX(ctx context.Context, host string) *MXDiagnostics {
+	if !v.mxDiagnosticsEnabled || host == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.connectTimeout)
+	defer cancel()
+
+	host = strings.TrimSuffix(host, ".")
+	diag := &MXDiagnostics{Host: host}
+	ips, err := lookupHostContext(ctx, host)
+	if err != nil {
+		return diag
+	}
+	diag.IPs = ips
+	for _, ip := range ips {
+		names, err := lookupAddrContext(ctx, ip)
+		if err != nil || len(names) == 0 {
+			continue
+		}
+		if diag.PTR == nil {
+			diag.PTR = make(map[string]string, len(ips))
+		}
+		diag.PTR[ip] = strings.TrimSuffix(names[0], ".")
+	}
+	return diag
+}
+
+// preferredMXHost returns the MX host of domain with the lowest preference,
+// empty when it can't be looked up
+func preferredMXHost(domain string) string {
+	mxRecords, err := lookupMX(domainToASCII(domain))
+	if err != nil || len(mxRecords) == 0 {
+		return ""
+	}
+	sort.SliceStable(mxRecords, func(i, j int) bool { return mxRecords[i].Pref < mxRecords[j].Pref })
+	return mxRecords[0].Host
+}
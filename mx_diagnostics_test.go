@@ -0,0 +1,68 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubMXDiagnostics answers the A/AAAA and PTR lookups of the MX diagnostics
+func stubMXDiagnostics(ips map[string][]string, ptr map[string][]string) func() {
+	originalHost := lookupHostContext
+	originalAddr := lookupAddrContext
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		if addrs, ok := ips[host]; ok {
+			return addrs, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	lookupAddrContext = func(ctx context.Context, addr string) ([]string, error) {
+		if names, ok := ptr[addr]; ok {
+			return names, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+	return func() {
+		lookupHostContext = originalHost
+		lookupAddrContext = originalAddr
+	}
+}
+
+func TestCheckSMTP_MXDiagnostics(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	defer stubMXDiagnostics(
+		map[string][]string{"mx.example.com": {"192.0.2.1", "192.0.2.2"}},
+		map[string][]string{"192.0.2.1": {"mx1.example.net."}},
+	)()
+
+	v := NewVerifier().EnableSMTPCheck().EnableMXDiagnostics()
+	ret, err := v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, &MXDiagnostics{
+		Host: "mx.example.com",
+		IPs:  []string{"192.0.2.1", "192.0.2.2"},
+		PTR:  map[string]string{"192.0.2.1": "mx1.example.net"},
+	}, ret.MXDiagnostics)
+
+	ret, err = v.DisableMXDiagnostics().CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.Nil(t, ret.MXDiagnostics)
+}
+
+func TestCheckSMTP_MXDiagnosticsWhenDialFails(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dialSMTPFunc = func(addr string, opts dialOptions) (*smtp.Client, error) {
+		return nil, errors.New("dial tcp 192.0.2.1:25: i/o timeout")
+	}
+	defer stubMXDiagnostics(nil, nil)()
+
+	v := NewVerifier().EnableSMTPCheck().EnableMXDiagnostics()
+	ret, err := v.CheckSMTP("example.com", "user")
+	assert.Error(t, err)
+	// failed lookups leave the fields empty
+	assert.Equal(t, &MXDiagnostics{Host: "mx.example.com"}, ret.MXDiagnostics)
+}
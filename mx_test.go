@@ -1,6 +1,9 @@
 package emailverifier
 
 import (
+	"context"
+	"net"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,3 +24,122 @@ func TestCheckNoMxOK(t *testing.T) {
 	assert.Nil(t, mx)
 	assert.Error(t, err, ErrNoSuchHost)
 }
+
+func TestCheckMX_ImplicitMX(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	originalLookupHost := lookupHostContext
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupHostContext = originalLookupHost
+	}()
+
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"192.0.2.1"}, nil
+	}
+
+	mx, err := verifier.CheckMX("vanity.example")
+	assert.NoError(t, err)
+	assert.False(t, mx.HasMXRecord)
+	assert.True(t, mx.ImplicitMX)
+	assert.Equal(t, []*net.MX{{Host: "vanity.example.", Pref: 0}}, mx.Records)
+}
+
+func TestCheckMX_NoMXNoHost(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	originalLookupHost := lookupHostContext
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupHostContext = originalLookupHost
+	}()
+
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	mx, err := verifier.CheckMX("nothing.example")
+	assert.Nil(t, mx)
+	assert.Error(t, err)
+}
+
+func TestCheckMX_NoImplicitMXOnServerFailure(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	originalLookupHost := lookupHostContext
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupHostContext = originalLookupHost
+	}()
+
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: domain, IsTemporary: true}
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"192.0.2.1"}, nil
+	}
+
+	mx, err := verifier.CheckMX("vanity.example")
+	assert.Nil(t, mx)
+	assert.EqualError(t, err, "lookup vanity.example: server misbehaving")
+}
+
+func TestCheckMX_NullMX(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	originalLookupHost := lookupHostContext
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupHostContext = originalLookupHost
+	}()
+
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: ".", Pref: 0}}, nil
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"192.0.2.1"}, nil
+	}
+
+	mx, err := verifier.CheckMX("nomail.example")
+	assert.NoError(t, err)
+	assert.True(t, mx.NullMX)
+	assert.False(t, mx.HasMXRecord)
+	assert.False(t, mx.ImplicitMX)
+}
+
+func TestVerify_NullMXSkipsSMTP(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: ".", Pref: 0}}, nil
+	}
+
+	ret, err := NewVerifier().EnableSMTPCheck().Verify("user@nomail.example")
+	assert.NoError(t, err)
+	assert.True(t, ret.NullMX)
+	assert.False(t, ret.HasMxRecords)
+	assert.Nil(t, ret.SMTP)
+	assert.Equal(t, reachableNo, ret.Reachable)
+	assert.Equal(t, int32(0), atomic.LoadInt32(dials))
+}
+
+func TestCheckSMTP_NullMX(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+	originalLookupMX := lookupMX
+	defer func() { lookupMX = originalLookupMX }()
+	lookupMX = func(domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: ".", Pref: 0}}, nil
+	}
+
+	_, err := NewVerifier().EnableSMTPCheck().CheckSMTP("nomail.example", "user")
+	var le *LookupError
+	if assert.ErrorAs(t, err, &le) {
+		assert.Equal(t, ErrNoSuchHost, le.Message)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(dials))
+}
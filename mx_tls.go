@@ -0,0 +1,99 @@
+package emailverifier
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MXTLSResult is the TLS detail of an MX host negotiated with STARTTLS
+type MXTLSResult struct {
+	Host        string    `json:"host"`         // MX host
+	STARTTLS    bool      `json:"starttls"`     // whether the host advertises STARTTLS, false means plaintext-only
+	Version     string    `json:"version"`      // negotiated TLS version, e.g. "TLS 1.3"
+	CipherSuite string    `json:"cipher_suite"` // negotiated cipher suite
+	Subject     string    `json:"subject"`      // subject of the leaf certificate
+	Issuer      string    `json:"issuer"`       // issuer of the leaf certificate
+	NotAfter    time.Time `json:"not_after"`    // expiry of the leaf certificate
+	NameMatches bool      `json:"name_matches"` // whether the leaf certificate is valid for the host name
+	Valid       bool      `json:"valid"`        // whether the certificate chain is trusted, non-expired and matches the host name
+	Error       string    `json:"error,omitempty"`
+}
+
+// CheckMXTLS connects to each MX host of domain, issues STARTTLS and reports the
+// negotiated TLS parameters and certificate. Certificates are inspected even when
+// they are invalid. Connections use the configured connect timeout and proxy.
+// Failures of a single host are reported in MXTLSResult.Error.
+func (v *Verifier) CheckMXTLS(domain string) ([]MXTLSResult, error) {
+	v = v.snapshot()
+	domain = domainToASCII(domain)
+	mxRecords, err := lookupMX(domain)
+	if err != nil {
+		return nil, ParseSMTPError(err)
+	}
+	if len(mxRecords) == 0 {
+		return nil, errors.New("No MX records found")
+	}
+
+	opts := v.dialOptions()
+	opts.proxyURI = v.proxies.candidates()[0]
+
+	results := make([]MXTLSResult, 0, len(mxRecords))
+	for _, mx := range mxRecords {
+		result, err := v.checkMXTLS(domain, mx.Host, opts)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// checkMXTLS negotiates STARTTLS with host, an MX host of domain
+func (v *Verifier) checkMXTLS(domain, host string, opts dialOptions) (MXTLSResult, error) {
+	serverName := strings.TrimSuffix(host, ".")
+	result := MXTLSResult{Host: serverName}
+
+	client, err := dialHost(host+smtpPort, opts)
+	if err != nil {
+		return result, err
+	}
+	defer client.Close()
+
+	if err = client.Hello(v.helloNameFor(domain)); err != nil {
+		return result, err
+	}
+	if result.STARTTLS, _ = client.Extension("STARTTLS"); !result.STARTTLS {
+		return result, nil
+	}
+
+	// the certificate is verified below so that invalid ones can be inspected
+	config := &tls.Config{ServerName: serverName, InsecureSkipVerify: true} //nolint:gosec
+	if err = client.StartTLS(config); err != nil {
+		return result, err
+	}
+	state, _ := client.TLSConnectionState()
+	result.Version = tls.VersionName(state.Version)
+	result.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) == 0 {
+		return result, errors.New("no peer certificate")
+	}
+
+	leaf := state.PeerCertificates[0]
+	result.Subject = leaf.Subject.String()
+	result.Issuer = leaf.Issuer.String()
+	result.NotAfter = leaf.NotAfter
+	result.NameMatches = leaf.VerifyHostname(serverName) == nil
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: serverName, Intermediates: intermediates})
+	result.Valid = err == nil
+
+	_ = client.Quit()
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package emailverifier
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// selfSignedCert returns a self-signed certificate for host
+func selfSignedCert(t *testing.T, host string, notAfter time.Time) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// fakeSTARTTLSServer is an SMTP server advertising STARTTLS with cert
+func fakeSTARTTLSServer(cert tls.Certificate) net.Conn {
+	client, server := net.Pipe()
+	go func() {
+		// the pipe is closed rather than the TLS conn, whose close_notify would block on the pipe
+		defer server.Close()
+		var conn net.Conn = server
+		r := bufio.NewReader(conn)
+		write := func(reply string) bool {
+			_, err := conn.Write([]byte(reply + "\r\n"))
+			return err == nil
+		}
+		if !write("220 fake.example.com ESMTP") {
+			return
+		}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.TrimRight(line, "\r\n"); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				write("250-fake.example.com\r\n250 STARTTLS")
+			case cmd == "STARTTLS":
+				write("220 Ready to start TLS")
+				conn = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+				r = bufio.NewReader(conn)
+			case cmd == "QUIT":
+				write("221 Bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+	return client
+}
+
+func TestCheckMXTLS(t *testing.T) {
+	cert := selfSignedCert(t, "mx.example.com", time.Now().Add(time.Hour))
+	originalLookupMX := lookupMX
+	originalDialSMTP := dialSMTPFunc
+	defer func() {
+		lookupMX = originalLookupMX
+		dialSMTPFunc = originalDialSMTP
+	}()
+	lookupMX = func(domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx.example.com.", Pref: 10}, {Host: "mx2.example.com.", Pref: 20}}, nil
+	}
+	dialSMTPFunc = func(addr string, opts dialOptions) (*smtp.Client, error) {
+		host, _, _ := net.SplitHostPort(addr)
+		return newSMTPClientOverConn(fakeSTARTTLSServer(cert), host, opts)
+	}
+
+	results, err := verifier.CheckMXTLS("example.com")
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "mx.example.com", results[0].Host)
+		assert.True(t, results[0].STARTTLS)
+		assert.Equal(t, "TLS 1.3", results[0].Version)
+		assert.NotEmpty(t, results[0].CipherSuite)
+		assert.Equal(t, "CN=mx.example.com", results[0].Subject)
+		assert.True(t, results[0].NameMatches)
+		assert.False(t, results[0].Valid) // self-signed
+		assert.Empty(t, results[0].Error)
+
+		assert.Equal(t, "mx2.example.com", results[1].Host)
+		assert.False(t, results[1].NameMatches)
+	}
+}
+
+func TestCheckMXTLS_PlaintextOnly(t *testing.T) {
+	defer useFakeSMTPServer(t, func(string) string { return "" })()
+
+	results, err := verifier.CheckMXTLS("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []MXTLSResult{{Host: "mx.example.com"}}, results)
+}
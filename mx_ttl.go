@@ -0,0 +1,242 @@
+package emailverifier
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	lookupMXTTLContext = lookupMXWithTTL
+	dnsServers         = systemDNSServers
+)
+
+// resolvConfPath is read by systemDNSServers to find the nameservers
+const resolvConfPath = "/etc/resolv.conf"
+
+// dnsQueryTimeout bounds each query of lookupMXWithTTL when ctx has no deadline
+const dnsQueryTimeout = 5 * time.Second
+
+// errNoDNSServer is returned by lookupMXWithTTL when no nameserver is configured
+var errNoDNSServer = errors.New("no DNS server configured")
+
+// MXRecord is a DNS MX record with its TTL
+type MXRecord struct {
+	Host string `json:"host"` // host name of the mail server, with a trailing dot
+	Pref uint16 `json:"pref"` // preference of the record, lower is preferred
+	TTL  uint32 `json:"ttl"`  // time to live of the record in seconds, zero when unknown
+}
+
+// mxRecords converts records without TTL to MXRecord
+func mxRecords(records []*net.MX) []MXRecord {
+	if len(records) == 0 {
+		return nil
+	}
+	ret := make([]MXRecord, 0, len(records))
+	for _, r := range records {
+		ret = append(ret, MXRecord{Host: r.Host, Pref: r.Pref})
+	}
+	return ret
+}
+
+// netMX converts records to net.MX, dropping their TTL
+func netMX(records []MXRecord) []*net.MX {
+	ret := make([]*net.MX, 0, len(records))
+	for _, r := range records {
+		ret = append(ret, &net.MX{Host: r.Host, Pref: r.Pref})
+	}
+	return ret
+}
+
+// systemDNSServers returns the nameservers of resolv.conf as host:port
+func systemDNSServers() []string {
+	f, err := os.Open(resolvConfPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		// strip the zone of link-local IPv6 servers
+		if ip := net.ParseIP(strings.SplitN(fields[1], "%", 2)[0]); ip != nil {
+			servers = append(servers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	return servers
+}
+
+// lookupMXWithTTL queries the MX records of domain and their TTL from the
+// system nameservers, sorted by preference. The nameservers are tried in order,
+// a query truncated over UDP is retried over TCP.
+func lookupMXWithTTL(ctx context.Context, domain string) ([]MXRecord, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(domain, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	return queryServers(ctx, func(server string) ([]MXRecord, error) {
+		return queryMX(ctx, server, name)
+	})
+}
+
+// queryServers calls query with the system nameservers in order until one answers,
+// a not found error is returned right away
+func queryServers[T any](ctx context.Context, query func(server string) (T, error)) (T, error) {
+	var zero T
+	servers := dnsServers()
+	if len(servers) == 0 {
+		return zero, errNoDNSServer
+	}
+
+	var lastErr error
+	for _, server := range servers {
+		ret, err := query(server)
+		if err == nil {
+			return ret, nil
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return zero, err
+		}
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		lastErr = err
+	}
+	return zero, lastErr
+}
+
+// queryMX sends the MX query of name to server and parses its answer
+func queryMX(ctx context.Context, server string, name dnsmessage.Name) ([]MXRecord, error) {
+	resp, err := exchangeMX(ctx, server, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []MXRecord
+	for _, answer := range resp.Answers {
+		mx, ok := answer.Body.(*dnsmessage.MXResource)
+		if !ok {
+			continue
+		}
+		records = append(records, MXRecord{Host: mx.MX.String(), Pref: mx.Pref, TTL: answer.Header.TTL})
+	}
+	if len(records) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: strings.TrimSuffix(name.String(), "."), Server: server, IsNotFound: true}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+	return records, nil
+}
+
+// dnsQueryID returns a random query ID, drawn from crypto/rand so that the
+// responses of an off-path attacker can't be matched to the queries
+func dnsQueryID() (uint16, error) {
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b[:]), nil
+}
+
+// exchangeMX sends the MX query of name to server and returns its successful response
+func exchangeMX(ctx context.Context, server string, name dnsmessage.Name) (*dnsmessage.Message, error) {
+	id, err := dnsQueryID()
+	if err != nil {
+		return nil, err
+	}
+	query, err := (&dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: name, Type: dnsmessage.TypeMX, Class: dnsmessage.ClassINET},
+		},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dnsQueryTimeout)
+		defer cancel()
+	}
+	resp, err := exchangeDNS(ctx, "udp", server, query)
+	if err == nil && resp.Header.Truncated {
+		resp, err = exchangeDNS(ctx, "tcp", server, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header.ID != id {
+		return nil, errors.New("DNS response ID mismatch")
+	}
+
+	domain := strings.TrimSuffix(name.String(), ".")
+	switch resp.Header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, &net.DNSError{Err: "no such host", Name: domain, Server: server, IsNotFound: true}
+	default:
+		return nil, &net.DNSError{Err: "server misbehaving: " + resp.Header.RCode.String(), Name: domain, Server: server, IsTemporary: true}
+	}
+	return resp, nil
+}
+
+// exchangeDNS sends query to server over network and returns the parsed response
+func exchangeDNS(ctx context.Context, network, server string, query []byte) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	buf := make([]byte, 65535)
+	var n int
+	if network == "tcp" {
+		// messages over TCP are prefixed by their length, RFC 1035 section 4.2.2
+		msg := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(msg, uint16(len(query)))
+		copy(msg[2:], query)
+		if _, err = conn.Write(msg); err != nil {
+			return nil, err
+		}
+		if _, err = io.ReadFull(conn, buf[:2]); err != nil {
+			return nil, err
+		}
+		n = int(binary.BigEndian.Uint16(buf[:2]))
+		if _, err = io.ReadFull(conn, buf[:n]); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = conn.Write(query); err != nil {
+			return nil, err
+		}
+		if n, err = conn.Read(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf[:n]); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
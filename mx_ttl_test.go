@@ -0,0 +1,119 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// useFakeDNSServer answers the MX queries over UDP with records, or NXDOMAIN
+// when the domain has none, and points dnsServers to it
+func useFakeDNSServer(t *testing.T, records map[string][]MXRecord) func() {
+	return useFakeCNAMEServer(t, records, nil)
+}
+
+// useFakeCNAMEServer is useFakeDNSServer answering the names of cnames with
+// their CNAME only, like a nameserver which doesn't follow the chain
+func useFakeCNAMEServer(t *testing.T, records map[string][]MXRecord, cnames map[string]string) func() {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.Header.ID, Response: true, RCode: dnsmessage.RCodeNameError},
+				Questions: query.Questions,
+			}
+			q := query.Questions[0]
+			if target, ok := cnames[q.Name.String()]; ok {
+				resp.Header.RCode = dnsmessage.RCodeSuccess
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET},
+					Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(target)},
+				})
+			} else if mx, ok := records[q.Name.String()]; ok {
+				resp.Header.RCode = dnsmessage.RCodeSuccess
+				for _, r := range mx {
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{
+						Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeMX, Class: dnsmessage.ClassINET, TTL: r.TTL},
+						Body:   &dnsmessage.MXResource{Pref: r.Pref, MX: dnsmessage.MustNewName(r.Host)},
+					})
+				}
+			}
+			packed, err := resp.Pack()
+			if err == nil {
+				_, _ = conn.WriteTo(packed, addr)
+			}
+		}
+	}()
+
+	original := dnsServers
+	dnsServers = func() []string { return []string{conn.LocalAddr().String()} }
+	return func() {
+		dnsServers = original
+		_ = conn.Close()
+	}
+}
+
+func TestCheckMX_TTL(t *testing.T) {
+	defer useFakeDNSServer(t, map[string][]MXRecord{
+		"example.com.": {
+			{Host: "mx2.example.com.", Pref: 20, TTL: 600},
+			{Host: "mx1.example.com.", Pref: 10, TTL: 300},
+		},
+	})()
+
+	v := NewVerifier().EnableMXTTL()
+	mx, err := v.CheckMX("example.com")
+	assert.NoError(t, err)
+	assert.True(t, mx.HasMXRecord)
+	assert.Equal(t, []MXRecord{
+		{Host: "mx1.example.com.", Pref: 10, TTL: 300},
+		{Host: "mx2.example.com.", Pref: 20, TTL: 600},
+	}, mx.MXRecords)
+	assert.Equal(t, []*net.MX{{Host: "mx1.example.com.", Pref: 10}, {Host: "mx2.example.com.", Pref: 20}}, mx.Records)
+}
+
+func TestLookupMXWithTTL_NotFound(t *testing.T) {
+	defer useFakeDNSServer(t, nil)()
+
+	_, err := lookupMXWithTTL(context.Background(), "nothing.example")
+	var dnsErr *net.DNSError
+	if assert.True(t, errors.As(err, &dnsErr)) {
+		assert.True(t, dnsErr.IsNotFound)
+	}
+}
+
+func TestCheckMX_TTLFallback(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	originalLookupMXTTL := lookupMXTTLContext
+	defer func() {
+		lookupMXContext = originalLookupMX
+		lookupMXTTLContext = originalLookupMXTTL
+	}()
+
+	lookupMXTTLContext = func(ctx context.Context, domain string) ([]MXRecord, error) {
+		return nil, errNoDNSServer
+	}
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx.example.com.", Pref: 10}}, nil
+	}
+
+	mx, err := NewVerifier().EnableMXTTL().CheckMX("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []MXRecord{{Host: "mx.example.com.", Pref: 10}}, mx.MXRecords)
+}
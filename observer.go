@@ -0,0 +1,24 @@
+package emailverifier
+
+import "time"
+
+// Observer receives events of the verification process, e.g. to export metrics.
+// Methods are called synchronously on the verifying goroutine and may be called
+// concurrently, so implementations must be fast and safe for concurrent use.
+// Embed NopObserver to only implement the events of interest.
+type Observer interface {
+	// OnSMTPDial is called after connecting to the SMTP server of a domain,
+	// host is the MX host connected to, or the domain when no MX host could be connected.
+	OnSMTPDial(host string, dur time.Duration, err error)
+	// OnVerifyDone is called when Verify finishes, including on error paths.
+	OnVerifyDone(email string, r *Result)
+}
+
+// NopObserver is an Observer which ignores all events
+type NopObserver struct{}
+
+// OnSMTPDial implements Observer
+func (NopObserver) OnSMTPDial(string, time.Duration, error) {}
+
+// OnVerifyDone implements Observer
+func (NopObserver) OnVerifyDone(string, *Result) {}
@@ -0,0 +1,68 @@
+package emailverifier
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	NopObserver
+	mu        sync.Mutex
+	dialHosts []string
+	dialErrs  []error
+	done      []*Result
+}
+
+func (o *recordingObserver) OnSMTPDial(host string, dur time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.dialHosts = append(o.dialHosts, host)
+	o.dialErrs = append(o.dialErrs, err)
+}
+
+func (o *recordingObserver) OnVerifyDone(email string, r *Result) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.done = append(o.done, r)
+}
+
+func TestObserver_OnVerifyDoneInvalidSyntax(t *testing.T) {
+	obs := &recordingObserver{}
+	v := NewVerifier().WithObserver(obs)
+
+	ret, err := v.Verify("invalid")
+	assert.NoError(t, err)
+	if assert.Len(t, obs.done, 1) {
+		assert.Equal(t, ret, obs.done[0])
+	}
+}
+
+func TestObserver_OnSMTPDialError(t *testing.T) {
+	originalLookupMX := lookupMX
+	defer func() {
+		lookupMX = originalLookupMX
+	}()
+	lookupMX = func(domain string) ([]*net.MX, error) {
+		return nil, errors.New("lookup mx.invalid: server misbehaving")
+	}
+
+	obs := &recordingObserver{}
+	v := NewVerifier().EnableSMTPCheck().WithObserver(obs)
+
+	_, err := v.CheckSMTP("mx.invalid", "user")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"mx.invalid"}, obs.dialHosts)
+	assert.Error(t, obs.dialErrs[0])
+}
+
+func TestWithObserver_Nil(t *testing.T) {
+	v := NewVerifier().WithObserver(nil)
+	assert.NotPanics(t, func() {
+		_, _ = v.Verify("invalid")
+	})
+}
@@ -0,0 +1,93 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// openRelayProbeDomain is the external domain of the RCPT address of the open relay probe,
+// it is reserved by RFC 2606 so nothing could be delivered even if DATA were sent
+const openRelayProbeDomain = "example.net"
+
+// OpenRelay is the outcome of the open relay probe of an MX host
+type OpenRelay struct {
+	Host               string   `json:"host"`                 // MX host probed
+	NullSenderAccepted bool     `json:"null_sender_accepted"` // whether MAIL FROM:<> was accepted
+	Open               bool     `json:"open"`                 // whether the RCPT of an external address was accepted, i.e. the host relays
+	Response           string   `json:"response"`             // last reply of the server, i.e. how it declined or accepted
+	Transcript         []string `json:"transcript,omitempty"` // SMTP conversation, when the debug transcript is enabled
+}
+
+// CheckOpenRelay probes whether mxHost is an open relay: it sends MAIL FROM:<>
+// (the null sender) followed by the RCPT of an address of an external domain,
+// and stops before DATA, so no message is ever sent.
+//
+// This is an intrusive check, it may be logged as an abuse attempt by the host
+// and should only be run against hosts you are allowed to audit. It must be enabled
+// with EnableOpenRelayProbe. mxHost is a host name or an IP address without port,
+// the configured timeouts and proxies are used to connect. Errors are only returned
+// when the host can't be connected or rejects the greeting.
+func (v *Verifier) CheckOpenRelay(mxHost string) (*OpenRelay, error) {
+	v = v.snapshot()
+	return v.checkOpenRelay(context.Background(), mxHost)
+}
+
+// checkOpenRelay is CheckOpenRelay bound to ctx
+func (v *Verifier) checkOpenRelay(ctx context.Context, mxHost string) (*OpenRelay, error) {
+	if !v.openRelayProbeEnabled {
+		return nil, errors.New("open relay probe is disabled, see EnableOpenRelayProbe")
+	}
+
+	// the replies are read from the transcript, even when the debug transcript is disabled,
+	// and the probe always gets a connection of its own
+	opts := v.smtpDialOptions(ctx, mxHost)
+	tr := opts.transcript
+	if tr == nil {
+		tr = &transcript{}
+		opts.transcript = tr
+	}
+	opts.pool = nil
+
+	dialStart := time.Now()
+	client, err := v.dialMX(mxHost, opts)
+	v.observer.OnSMTPDial(mxHost, time.Since(dialStart), err)
+	if err != nil {
+		return nil, ParseSMTPError(err)
+	}
+	defer quitSMTPClient(client)
+	stop := context.AfterFunc(ctx, func() { _ = client.Close() })
+	defer stop()
+
+	ret := &OpenRelay{Host: mxHost}
+	defer func() {
+		ret.Response = lastReply(tr.linesOf(mxHost))
+		if v.transcriptEnabled {
+			ret.Transcript = tr.linesOf(mxHost)
+		}
+	}()
+
+	if err = client.Hello(v.helloNameFor(mxHost)); err != nil {
+		return ret, ParseSMTPError(err)
+	}
+	if err = client.Mail(""); err != nil {
+		return ret, nil
+	}
+	ret.NullSenderAccepted = true
+	if err = client.Rcpt(GenerateRandomEmail(openRelayProbeDomain)); err != nil {
+		return ret, nil
+	}
+	ret.Open = true
+	return ret, nil
+}
+
+// lastReply returns the last line sent by the server in the transcript lines
+func lastReply(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if reply, ok := strings.CutPrefix(lines[i], transcriptServerPrefix); ok {
+			return reply
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package emailverifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOpenRelay_Disabled(t *testing.T) {
+	_, err := NewVerifier().CheckOpenRelay("mx.example.com")
+	assert.Error(t, err)
+}
+
+func TestCheckOpenRelay(t *testing.T) {
+	cases := []struct {
+		name     string
+		respond  func(cmd string) string
+		expected *OpenRelay
+	}{
+		{
+			name: "relay denied",
+			respond: func(cmd string) string {
+				if strings.HasPrefix(cmd, "RCPT") {
+					return "554 5.7.1 Relay access denied"
+				}
+				return ""
+			},
+			expected: &OpenRelay{Host: "mx.example.com", NullSenderAccepted: true, Response: "554 5.7.1 Relay access denied"},
+		},
+		{
+			name: "null sender rejected",
+			respond: func(cmd string) string {
+				if strings.HasPrefix(cmd, "MAIL") {
+					return "550 5.7.1 Null sender not allowed"
+				}
+				return ""
+			},
+			expected: &OpenRelay{Host: "mx.example.com", Response: "550 5.7.1 Null sender not allowed"},
+		},
+		{
+			name:     "open relay",
+			respond:  func(string) string { return "" },
+			expected: &OpenRelay{Host: "mx.example.com", NullSenderAccepted: true, Open: true, Response: "250 OK"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer useFakeSMTPServer(t, c.respond)()
+
+			ret, err := NewVerifier().EnableOpenRelayProbe().CheckOpenRelay("mx.example.com")
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, ret)
+		})
+	}
+}
+
+func TestCheckOpenRelay_StopsBeforeData(t *testing.T) {
+	defer useFakeSMTPServer(t, func(string) string { return "" })()
+
+	ret, err := NewVerifier().EnableOpenRelayProbe().EnableDebugTranscript().CheckOpenRelay("mx.example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, ret.Transcript, "C: MAIL FROM:<> BODY=8BITMIME")
+	for _, line := range ret.Transcript {
+		assert.False(t, strings.HasPrefix(line, "C: DATA"), line)
+		assert.False(t, strings.HasPrefix(line, "C: RCPT TO:") && !strings.HasSuffix(line, "@"+openRelayProbeDomain+">"), line)
+	}
+}
@@ -0,0 +1,47 @@
+package emailverifier
+
+import (
+	"net"
+	"strings"
+)
+
+// parkingMXDomains are the domains of the MX hosts of domain parking services,
+// domain marketplaces and registrar placeholders, such domains accept any mail
+var parkingMXDomains = map[string]bool{
+	"sedoparking.com":       true,
+	"afternic.com":          true,
+	"registrar-servers.com": true,
+	"parkingcrew.net":       true,
+	"bodis.com":             true,
+	"above.com":             true,
+	"dan.com":               true,
+	"hugedomains.com":       true,
+	"undeveloped.com":       true,
+	"parklogic.com":         true,
+}
+
+// parkingMXSet is the concurrent safe set of parkingMXDomains, extended by AddParkingMXDomains
+var parkingMXSet = newDomainSet(parkingMXDomains)
+
+// IsParkingMX checks if host is an MX host of a domain parking service or registrar
+// placeholder, i.e. the host or one of its parent domains is a parking MX domain
+// (e.g. "mx1.sedoparking.com"). The match is case-insensitive.
+func (v *Verifier) IsParkingMX(host string) bool {
+	host = cleanDomain(host)
+	for ; strings.Contains(host, "."); host = parentDomain(host) {
+		if parkingMXSet.contains(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// isParked reports whether one of the MX records points at a parking MX host
+func (v *Verifier) isParked(records []*net.MX) bool {
+	for _, r := range records {
+		if v.IsParkingMX(r.Host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsParkingMX(t *testing.T) {
+	assert.True(t, verifier.IsParkingMX("mx1.SedoParking.com."))
+	assert.True(t, verifier.IsParkingMX("eforward1.registrar-servers.com"))
+	assert.False(t, verifier.IsParkingMX("aspmx.l.google.com."))
+	assert.False(t, verifier.IsParkingMX("com"))
+
+	v := NewVerifier().AddParkingMXDomains("Parking.Example.")
+	assert.True(t, v.IsParkingMX("mx.parking.example"))
+	v.RemoveParkingMXDomains("parking.example")
+	assert.False(t, v.IsParkingMX("mx.parking.example"))
+}
+
+func TestVerify_Parked(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		if domain == "forsale.example" {
+			return []*net.MX{{Host: "mx.sedoparking.com.", Pref: 10}}, nil
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	v := NewVerifier()
+	ret, err := v.Verify("user@forsale.example")
+	assert.NoError(t, err)
+	assert.True(t, ret.Parked)
+
+	ret, err = v.Verify("user@business.example")
+	assert.NoError(t, err)
+	assert.False(t, ret.Parked)
+}
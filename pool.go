@@ -0,0 +1,240 @@
+package emailverifier
+
+import (
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPoolIdleTimeout is how long an idle client is kept when no idle timeout is given
+const defaultPoolIdleTimeout = 30 * time.Second
+
+// smtpPool keeps the SMTP clients of successful checks per MX host, HELO/EHLO name and proxy,
+// so the next checks against the same host reuse them instead of reconnecting.
+// A client is reset with RSET before going back to the pool, a client failing
+// the reset, used by a failed check or idle for longer than idleTimeout is closed.
+// All methods are no-ops on a nil pool.
+type smtpPool struct {
+	mu          sync.Mutex
+	maxPerHost  int
+	idleTimeout time.Duration
+	idle        map[string][]pooledClient  // idle clients per pool key, most recently used last
+	conns       map[*smtp.Client]net.Conn  // connections of the clients, to refresh their deadlines
+	reused      map[*smtp.Client]time.Time // clients handed out by the pool, their greeting was already sent, with the time they went idle
+	closed      bool
+	stopCh      chan struct{}
+}
+
+// pooledClient is an idle client of the pool
+type pooledClient struct {
+	client    *smtp.Client
+	idleSince time.Time
+}
+
+// newSMTPPool creates a pool keeping up to maxPerHost idle clients per host
+// and starts evicting the clients idle for longer than idleTimeout
+func newSMTPPool(maxPerHost int, idleTimeout time.Duration) *smtpPool {
+	p := &smtpPool{
+		maxPerHost:  maxPerHost,
+		idleTimeout: idleTimeout,
+		idle:        map[string][]pooledClient{},
+		conns:       map[*smtp.Client]net.Conn{},
+		reused:      map[*smtp.Client]time.Time{},
+		stopCh:      make(chan struct{}),
+	}
+	go p.evictLoop()
+	return p
+}
+
+// poolKey is the key of the clients connected to host with opts: greeted with its
+// HELO/EHLO name, through the proxy set by SMTPOptions or the one of the environment,
+// so a check never gets a client dialed through another proxy
+func poolKey(host string, opts dialOptions) string {
+	proxyURI := opts.proxyURI
+	if proxyURI == "" && opts.proxyFromEnv {
+		proxyURI = environmentProxy(host)
+	}
+	return strings.ToLower(strings.TrimSuffix(host, ".")) + " " + opts.helloName + " " + proxyURI
+}
+
+// track records the connection of a client dialed while the pool is enabled
+func (p *smtpPool) track(client *smtp.Client, conn net.Conn) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conns[client] = conn
+}
+
+// get returns an idle client of the first host of mxRecords which has one,
+// nil when there is none
+func (p *smtpPool) get(mxRecords []*net.MX, opts dialOptions) (*smtp.Client, *net.MX) {
+	if p == nil {
+		return nil, nil
+	}
+	for _, mx := range mxRecords {
+		if client := p.getHost(mx.Host, opts); client != nil {
+			return client, mx
+		}
+	}
+	return nil, nil
+}
+
+// getHost returns the most recently used idle client of host, nil when there is none
+func (p *smtpPool) getHost(host string, opts dialOptions) *smtp.Client {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := poolKey(host, opts)
+	clients := p.idle[key]
+	for len(clients) > 0 {
+		c := clients[len(clients)-1]
+		clients = clients[:len(clients)-1]
+		if time.Since(c.idleSince) > p.idleTimeout || p.refreshDeadline(c.client, opts) != nil {
+			p.evict(c.client)
+			continue
+		}
+		p.idle[key] = clients
+		p.reused[c.client] = c.idleSince
+		return c.client
+	}
+	delete(p.idle, key)
+	return nil
+}
+
+// isReused reports whether client was handed out by the pool
+func (p *smtpPool) isReused(client *smtp.Client) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, found := p.reused[client]
+	return found
+}
+
+// idleSince returns when client handed out by the pool went idle, i.e. the end of
+// its previous check, zero when it wasn't handed out by the pool
+func (p *smtpPool) idleSince(client *smtp.Client) time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reused[client]
+}
+
+// release gives back the client of a check against host, it is kept for the next
+// checks when the check succeeded and the client is reset, it is closed otherwise
+func (p *smtpPool) release(client *smtp.Client, host string, opts dialOptions, err error) {
+	if p == nil {
+		quitSMTPClient(client)
+		return
+	}
+
+	p.mu.Lock()
+	delete(p.reused, client)
+	keep := err == nil && !p.closed && p.refreshDeadline(client, opts) == nil
+	p.mu.Unlock()
+
+	// RSET is sent without holding the lock, as the server may be slow to reply
+	if keep && client.Reset() == nil {
+		p.mu.Lock()
+		key := poolKey(host, opts)
+		if !p.closed && len(p.idle[key]) < p.maxPerHost {
+			p.idle[key] = append(p.idle[key], pooledClient{client: client, idleSince: time.Now()})
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	delete(p.conns, client)
+	p.mu.Unlock()
+	quitSMTPClient(client)
+}
+
+// close quits every idle client and stops pooling the released ones
+func (p *smtpPool) close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stopCh)
+	var clients []*smtp.Client
+	for key, idle := range p.idle {
+		for _, c := range idle {
+			delete(p.conns, c.client)
+			clients = append(clients, c.client)
+		}
+		delete(p.idle, key)
+	}
+	p.mu.Unlock()
+
+	for _, client := range clients {
+		quitSMTPClient(client)
+	}
+}
+
+// evictLoop evicts the idle clients periodically until the pool is closed
+func (p *smtpPool) evictLoop() {
+	ticker := time.NewTicker(max(p.idleTimeout/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.evictIdle()
+		}
+	}
+}
+
+// evictIdle closes the clients idle for longer than the idle timeout
+func (p *smtpPool) evictIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, clients := range p.idle {
+		kept := clients[:0]
+		for _, c := range clients {
+			if time.Since(c.idleSince) > p.idleTimeout {
+				p.evict(c.client)
+				continue
+			}
+			kept = append(kept, c)
+		}
+		if len(kept) == 0 {
+			delete(p.idle, key)
+			continue
+		}
+		p.idle[key] = kept
+	}
+}
+
+// evict forgets client and closes it, p.mu must be held
+func (p *smtpPool) evict(client *smtp.Client) {
+	delete(p.conns, client)
+	delete(p.reused, client)
+	_ = client.Close()
+}
+
+// refreshDeadline extends the deadline of the connection of client by the operation timeout,
+// as the deadline set when dialing is long gone for a reused client. p.mu must be held.
+func (p *smtpPool) refreshDeadline(client *smtp.Client, opts dialOptions) error {
+	conn, found := p.conns[client]
+	if !found {
+		return nil
+	}
+	return conn.SetDeadline(time.Now().Add(opts.operationTimeout))
+}
@@ -0,0 +1,170 @@
+package emailverifier
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordCommands records the commands received by a fakeSMTPServer answered by respond
+func recordCommands(respond func(cmd string) string) (func(cmd string) string, func() []string) {
+	var mu sync.Mutex
+	var commands []string
+	record := func(cmd string) string {
+		mu.Lock()
+		commands = append(commands, cmd)
+		mu.Unlock()
+		return respond(cmd)
+	}
+	recorded := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), commands...)
+	}
+	return record, recorded
+}
+
+// countCommands counts the commands starting with prefix
+func countCommands(commands []string, prefix string) int {
+	n := 0
+	for _, cmd := range commands {
+		if strings.HasPrefix(cmd, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestConnectionPool_ReusesClients(t *testing.T) {
+	respond, commands := recordCommands(rejectRandomRcpt)
+	defer useFakeSMTPServer(t, respond)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableConnectionPool(2, time.Minute)
+	defer v.Close()
+	for i := 0; i < 3; i++ {
+		ret, err := v.CheckSMTP("example.com", "user")
+		assert.NoError(t, err)
+		assert.Equal(t, &SMTP{HostExists: true, Extensions: fakeExtensions, CatchAllStatus: CatchAllNo, Deliverable: true}, ret)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+	assert.Equal(t, 1, countCommands(commands(), "EHLO"))
+	assert.Equal(t, 3, countCommands(commands(), "RSET"))
+	assert.Equal(t, 0, countCommands(commands(), "QUIT"))
+
+	// CheckSMTPWithMX shares the clients of the host
+	_, err := v.CheckSMTPWithMX("MX.example.com", "example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	// Close quits the idle clients
+	assert.NoError(t, v.Close())
+	assert.Eventually(t, func() bool { return countCommands(commands(), "QUIT") == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestConnectionPool_CloseIsIdempotent(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableConnectionPool(1, time.Minute)
+	assert.NoError(t, v.Close())
+	assert.NoError(t, v.Close())
+
+	// the closed verifier still checks, without pooling the clients
+	for i := 0; i < 2; i++ {
+		_, err := v.CheckSMTP("example.com", "user")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+	assert.NoError(t, NewVerifier().Close())
+}
+
+func TestConnectionPool_EvictsOnError(t *testing.T) {
+	defer useFakeSMTPServer(t, func(cmd string) string {
+		if strings.HasPrefix(cmd, "RCPT TO:<busy@") {
+			return "450 4.2.1 Mailbox busy"
+		}
+		return rejectRandomRcpt(cmd)
+	})()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableConnectionPool(1, time.Minute)
+	defer v.Close()
+	_, err := v.CheckSMTP("example.com", "busy")
+	assert.Error(t, err)
+	_, err = v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+}
+
+func TestConnectionPool_EvictsIdleClients(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableConnectionPool(1, 20*time.Millisecond)
+	defer v.Close()
+	_, err := v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	_, err = v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+}
+
+func TestConnectionPool_KeyedByHelloName(t *testing.T) {
+	respond, commands := recordCommands(func(string) string { return "" })
+	defer useFakeSMTPServer(t, respond)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().DisableCatchAllCheck().EnableConnectionPool(1, time.Minute).
+		HelloNameFunc(func(domain string) string { return "mta." + domain })
+	defer v.Close()
+	_, err := v.CheckSMTPWithMX("mx.example.com", "example.com", "user")
+	assert.NoError(t, err)
+	_, err = v.CheckSMTPWithMX("mx.example.com", "example.net", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+	assert.Contains(t, commands(), "EHLO mta.example.com")
+	assert.Contains(t, commands(), "EHLO mta.example.net")
+}
+
+func TestConnectionPool_KeyedByProxy(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableConnectionPool(1, time.Minute)
+	defer v.Close()
+	_, err := v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+
+	// the client dialed directly isn't reused through a proxy
+	opts := SMTPOptions{Proxy: "socks5://proxy.example.com:1080"}
+	_, err = v.CheckSMTPWithOptions("example.com", "user", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+	_, err = v.CheckSMTPWithOptions("example.com", "user", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+
+	t.Setenv("ALL_PROXY", "socks5://env-proxy.example.com:1080")
+	_, err = v.ProxyFromEnvironment().CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(dials))
+}
+
+func TestConnectionPool_Disabled(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableConnectionPool(1, time.Minute).DisableConnectionPool()
+	for i := 0; i < 2; i++ {
+		_, err := v.CheckSMTP("example.com", "user")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+	assert.Nil(t, v.EnableConnectionPool(0, 0).pool)
+}
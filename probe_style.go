@@ -0,0 +1,69 @@
+package emailverifier
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// ProbeStyle is the style of the random local part of the catch-all probe address
+type ProbeStyle string
+
+const (
+	// ProbeStyleAlphanumeric is 32 random lowercase letters and digits, as generated
+	// by GenerateRandomEmail. This is the default.
+	ProbeStyleAlphanumeric ProbeStyle = "alphanumeric"
+	// ProbeStyleRandomHex is 16 random hexadecimal digits, e.g. "3f9a0c71d2e84b65"
+	ProbeStyleRandomHex ProbeStyle = "random-hex"
+	// ProbeStylePronounceable alternates consonants and vowels, e.g. "tovaremik"
+	ProbeStylePronounceable ProbeStyle = "pronounceable"
+	// ProbeStyleNameLike mimics a firstname.lastname address made of invented
+	// pronounceable names followed by two digits, e.g. "kalen.moravi27", so it
+	// is unlikely to hit a real mailbox
+	ProbeStyleNameLike ProbeStyle = "name-like"
+)
+
+const (
+	hexDigits  = "0123456789abcdef"
+	consonants = "bcdfghjklmnprstvz"
+	vowels     = "aeiou"
+)
+
+// localPart returns a random local part of the style,
+// an unknown style generates a ProbeStyleAlphanumeric local part
+func (s ProbeStyle) localPart() string {
+	switch s {
+	case ProbeStyleRandomHex:
+		return randomString(hexDigits, 16)
+	case ProbeStylePronounceable:
+		return pronounceable(8 + rand.Intn(5)) //nolint:gosec
+	case ProbeStyleNameLike:
+		first := pronounceable(4 + rand.Intn(3))                     //nolint:gosec
+		last := pronounceable(5 + rand.Intn(3))                      //nolint:gosec
+		return fmt.Sprintf("%s.%s%02d", first, last, rand.Intn(100)) //nolint:gosec
+	default:
+		return randomString(alphanumeric, 32)
+	}
+}
+
+// randomString returns n random characters of alphabet
+func randomString(alphabet string, n int) string {
+	r := make([]byte, n)
+	for i := range r {
+		r[i] = alphabet[rand.Intn(len(alphabet))] //nolint:gosec
+	}
+	return string(r)
+}
+
+// pronounceable returns n random letters alternating consonants and vowels
+func pronounceable(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			b.WriteByte(consonants[rand.Intn(len(consonants))]) //nolint:gosec
+		} else {
+			b.WriteByte(vowels[rand.Intn(len(vowels))]) //nolint:gosec
+		}
+	}
+	return b.String()
+}
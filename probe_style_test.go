@@ -0,0 +1,38 @@
+package emailverifier
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeStyle_LocalPart(t *testing.T) {
+	patterns := map[ProbeStyle]string{
+		"":                      `^[a-z0-9]{32}$`,
+		ProbeStyleAlphanumeric:  `^[a-z0-9]{32}$`,
+		ProbeStyleRandomHex:     `^[0-9a-f]{16}$`,
+		ProbeStylePronounceable: `^([bcdfghjklmnprstvz][aeiou])+[bcdfghjklmnprstvz]?$`,
+		ProbeStyleNameLike:      `^[a-z]{4,6}\.[a-z]{5,7}[0-9]{2}$`,
+		"unknown":               `^[a-z0-9]{32}$`,
+	}
+	for style, pattern := range patterns {
+		for i := 0; i < 20; i++ {
+			local := style.localPart()
+			assert.Regexp(t, regexp.MustCompile(pattern), local, "style %q", style)
+			assert.True(t, IsAddressValid(local+"@example.com"), "style %q: %s", style, local)
+		}
+	}
+}
+
+func TestCheckSMTP_CatchAllProbeStyle(t *testing.T) {
+	respond, commands := recordCommands(rejectRandomRcpt)
+	defer useFakeSMTPServer(t, respond)()
+
+	v := NewVerifier().EnableSMTPCheck().EnableDebugTranscript().CatchAllProbeStyle(ProbeStyleNameLike)
+	ret, err := v.CheckSMTP("example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+	assert.Contains(t, ret.Transcript, "* catch-all probe style: name-like")
+	assert.Regexp(t, `^RCPT TO:<[a-z]+\.[a-z]+[0-9]{2}@example\.com>`, commands()[2])
+}
@@ -0,0 +1,125 @@
+package emailverifier
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// Mail providers recognized by DetectMailProvider
+const (
+	ProviderGoogle       = "google"       // Google Workspace and Gmail
+	ProviderMicrosoft365 = "microsoft365" // Microsoft 365 tenants (Exchange Online)
+	ProviderOutlook      = "outlook"      // consumer Outlook.com and Hotmail
+	ProviderYahoo        = "yahoo"        // Yahoo and AOL
+	ProviderZoho         = "zoho"         // Zoho Mail
+	ProviderMimecast     = "mimecast"     // Mimecast gateway
+	ProviderProofpoint   = "proofpoint"   // Proofpoint gateway
+)
+
+// DefaultMailProviderMX maps the domains of the MX hosts of mail providers to the provider,
+// an MX host matches a domain or any of its subdomains, the most specific domain winning.
+// It may be extended before the verifier is used.
+var DefaultMailProviderMX = map[string]string{
+	"google.com":                  ProviderGoogle,
+	"googlemail.com":              ProviderGoogle,
+	"mail.protection.outlook.com": ProviderMicrosoft365,
+	"olc.protection.outlook.com":  ProviderOutlook,
+	"yahoodns.net":                ProviderYahoo,
+	"zoho.com":                    ProviderZoho,
+	"zoho.eu":                     ProviderZoho,
+	"mimecast.com":                ProviderMimecast,
+	"pphosted.com":                ProviderProofpoint,
+}
+
+// ProviderBehavior is how a mail provider answers the SMTP check
+type ProviderBehavior struct {
+	// AcceptAllThenBounce is a provider whose gateway accepts any RCPT and bounces the
+	// unknown mailboxes later, so neither an accepted RCPT nor an accepted catch-all
+	// probe tells anything about the mailbox
+	AcceptAllThenBounce bool `json:"accept_all_then_bounce"`
+	// NoCatchAll is a provider which hosts no catch-all domains and whose servers answer
+	// the catch-all probe unreliably, e.g. Yahoo rate limits the extra RCPT. The probe is
+	// skipped and the catch-all status is "no", like the API verifier of the provider.
+	NoCatchAll bool `json:"no_catch_all"`
+	// MailboxNotFoundPhrases are phrases of the RCPT rejections of the provider meaning
+	// the mailbox doesn't exist, on top of MailboxNotFoundPhrases, e.g. the 554 "This user
+	// doesn't have a yahoo.com account" of Yahoo, read as a disabled mailbox otherwise
+	MailboxNotFoundPhrases []string `json:"mailbox_not_found_phrases,omitempty"`
+}
+
+// DefaultProviderBehaviors are the known behaviors of the providers of DefaultMailProviderMX,
+// a provider missing from it answers the SMTP check reliably. It may be changed before the
+// verifier is used, use SetProviderBehavior for the behaviors of a single verifier.
+var DefaultProviderBehaviors = map[string]ProviderBehavior{
+	ProviderMicrosoft365: {AcceptAllThenBounce: true},
+	ProviderYahoo:        {NoCatchAll: true, MailboxNotFoundPhrases: []string{"user doesn't have a"}},
+}
+
+// DetectMailProvider returns the provider hosting the mail of domain, recognized by its
+// most preferred MX host listed in DefaultMailProviderMX, e.g. ProviderMicrosoft365.
+// It is empty when the provider is unknown or the domain has no MX records.
+func (v *Verifier) DetectMailProvider(domain string) (string, error) {
+	v = v.snapshot()
+	mx, err := v.checkMX(context.Background(), domain)
+	if err != nil {
+		return "", err
+	}
+	if mx.ImplicitMX || len(mx.Records) == 0 {
+		return "", nil
+	}
+	// a backup MX host of a provider doesn't make it the provider of the domain
+	return mailProviderOf(sortMXRecords(mx.Records)[0].Host), nil
+}
+
+// SetProviderBehavior sets the behavior of provider for this verifier, over
+// DefaultProviderBehaviors, e.g. a zero ProviderBehavior trusts the SMTP check of
+// the Microsoft 365 tenants again
+func (v *Verifier) SetProviderBehavior(provider string, behavior ProviderBehavior) *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// the map is copied as snapshots of running checks share it
+	v.providerBehaviors = maps.Clone(v.providerBehaviors)
+	if v.providerBehaviors == nil {
+		v.providerBehaviors = map[string]ProviderBehavior{}
+	}
+	v.providerBehaviors[provider] = behavior
+	return v
+}
+
+// providerBehavior returns the behavior of provider set by SetProviderBehavior, then DefaultProviderBehaviors
+func (v *Verifier) providerBehavior(provider string) ProviderBehavior {
+	if behavior, ok := v.providerBehaviors[provider]; ok {
+		return behavior
+	}
+	return DefaultProviderBehaviors[provider]
+}
+
+// mailProviderOf returns the provider of the MX host, empty when it is unknown
+func mailProviderOf(host string) string {
+	for host = cleanDomain(host); host != ""; host = parentDomain(host) {
+		if provider, ok := DefaultMailProviderMX[host]; ok {
+			return provider
+		}
+	}
+	return ""
+}
+
+// applyProviderBehavior records the provider of the MX host the SMTP check s talked to.
+// An accepted RCPT of a provider accepting all recipients is no evidence of the mailbox:
+// the catch-all status becomes unknown and s.AcceptAllThenBounce keeps the result from
+// being reachable, a rejection is still trusted.
+func (v *Verifier) applyProviderBehavior(s *SMTP, mxHost string) {
+	s.Provider = mailProviderOf(mxHost)
+	if s.Provider == "" || !v.providerBehavior(s.Provider).AcceptAllThenBounce {
+		return
+	}
+	if !s.Deliverable && s.CatchAllStatus != CatchAllYes {
+		return
+	}
+	s.AcceptAllThenBounce = true
+	s.CatchAll = false
+	s.CatchAllStatus = CatchAllUnknown
+	s.Note = fmt.Sprintf("%s accepts any recipient at its gateway and may bounce unknown mailboxes later, the acceptance doesn't confirm the mailbox", s.Provider)
+	v.logger.Debug("provider accepts all recipients, the mailbox is unconfirmed", "host", mxHost, "provider", s.Provider)
+}
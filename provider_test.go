@@ -0,0 +1,125 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailProviderOf(t *testing.T) {
+	assert.Equal(t, ProviderMicrosoft365, mailProviderOf("Tenant-com.mail.protection.outlook.com."))
+	assert.Equal(t, ProviderOutlook, mailProviderOf("hotmail-com.olc.protection.outlook.com."))
+	assert.Equal(t, ProviderGoogle, mailProviderOf("aspmx.l.google.com"))
+	assert.Equal(t, ProviderYahoo, mailProviderOf("mta5.am0.yahoodns.net."))
+	assert.Equal(t, "", mailProviderOf("protection.outlook.com"))
+	assert.Equal(t, "", mailProviderOf("mx.example.com"))
+}
+
+func TestDetectMailProvider(t *testing.T) {
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		if domain == "tenant.com" {
+			return []*net.MX{{Host: "backup.example.net.", Pref: 20}, {Host: "tenant-com.mail.protection.outlook.com.", Pref: 10}}, nil
+		}
+		if domain == "self-hosted.com" {
+			return []*net.MX{{Host: "mx.self-hosted.com.", Pref: 10}, {Host: "aspmx.l.google.com.", Pref: 20}}, nil
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	provider, err := verifier.DetectMailProvider("tenant.com")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderMicrosoft365, provider)
+
+	provider, err = verifier.DetectMailProvider("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "", provider)
+
+	// only the most preferred MX host is considered, not the backup
+	provider, err = verifier.DetectMailProvider("self-hosted.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "", provider)
+}
+
+func TestCheckSMTP_AcceptAllThenBounce(t *testing.T) {
+	defer useFakeSMTPServer(t, func(cmd string) string { return "" })()
+
+	const mx = "tenant-com.mail.protection.outlook.com"
+	v := NewVerifier().EnableSMTPCheck()
+	ret, err := v.CheckSMTPWithMX(mx, "tenant.com", "nobody")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderMicrosoft365, ret.Provider)
+	assert.False(t, ret.Deliverable)
+	assert.True(t, ret.AcceptAllThenBounce)
+	assert.Equal(t, CatchAllUnknown, ret.CatchAllStatus)
+	assert.False(t, ret.CatchAll)
+	assert.Contains(t, ret.Note, "microsoft365 accepts any recipient")
+	assert.Equal(t, reachableUnknown, v.calculateReachable(Syntax{Valid: true}, ret))
+	assert.Equal(t, reachableNo, v.strictReachable(MailboxBackendSMTP, ret, nil))
+
+	// the behavior of the provider can be overridden
+	ret, err = v.SetProviderBehavior(ProviderMicrosoft365, ProviderBehavior{}).CheckSMTPWithMX(mx, "tenant.com", "nobody")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderMicrosoft365, ret.Provider)
+	assert.False(t, ret.AcceptAllThenBounce)
+	assert.Equal(t, CatchAllYes, ret.CatchAllStatus)
+	assert.Empty(t, ret.Note)
+}
+
+func TestCheckSMTP_AcceptAllThenBounceTrustsRejections(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+
+	ret, err := NewVerifier().EnableSMTPCheck().CheckSMTPWithMX("tenant-com.mail.protection.outlook.com", "tenant.com", "nobody")
+	assert.NoError(t, err)
+	assert.False(t, ret.Deliverable)
+	assert.False(t, ret.AcceptAllThenBounce)
+	assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+	assert.Equal(t, reachableNo, NewVerifier().EnableSMTPCheck().calculateReachable(Syntax{Valid: true}, ret))
+}
+
+func TestCheckSMTP_YahooCatchAllHeuristics(t *testing.T) {
+	respond, commands := recordCommands(func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, "RCPT TO:<gone@"):
+			return "554 delivery error: dd This user doesn't have a yahoo.com account (gone@yahoo.com) [0] - mta1001.mail.gq1.yahoo.com"
+		case strings.HasPrefix(cmd, "RCPT TO:<disabled@"):
+			return "554 delivery error: dd This mailbox is disabled (554.30)"
+		}
+		return ""
+	})
+	defer useFakeSMTPServer(t, respond)()
+
+	const mx = "mta5.am0.yahoodns.net"
+	v := NewVerifier().EnableSMTPCheck().EnableDebugTranscript()
+	ret, err := v.CheckSMTPWithMX(mx, "yahoo.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderYahoo, ret.Provider)
+	assert.True(t, ret.Deliverable)
+	assert.False(t, ret.CatchAll)
+	assert.Equal(t, CatchAllNo, ret.CatchAllStatus)
+	assert.Contains(t, ret.Transcript, transcriptNotePrefix+"yahoo hosts no catch-all domains, catch-all probe skipped")
+	// the catch-all probe isn't sent
+	assert.Equal(t, 1, countCommands(commands(), "RCPT"))
+	assert.Equal(t, reachableYes, v.strictReachable(MailboxBackendSMTP, ret, nil))
+
+	ret, err = v.CheckSMTPWithMX(mx, "yahoo.com", "gone")
+	assert.NoError(t, err)
+	assert.False(t, ret.Deliverable)
+	assert.False(t, ret.Disabled)
+	assert.Equal(t, ErrMailboxNotFound, ret.Error.Message)
+	assert.Equal(t, reachableNo, v.calculateReachable(Syntax{Valid: true}, ret))
+
+	ret, err = v.CheckSMTPWithMX(mx, "yahoo.com", "disabled")
+	assert.NoError(t, err)
+	assert.True(t, ret.Disabled)
+	assert.Equal(t, ErrNotAllowed, ret.Error.Message)
+
+	// other providers keep probing
+	ret, err = v.CheckSMTPWithMX("mx.example.com", "example.com", "gone")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchAllYes, ret.CatchAllStatus)
+}
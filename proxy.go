@@ -0,0 +1,179 @@
+package emailverifier
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyStrategy controls how a proxy is picked from the proxy pool for each SMTP check.
+type ProxyStrategy int
+
+const (
+	// ProxyStrategyRoundRobin picks the proxies of the pool in turn.
+	ProxyStrategyRoundRobin ProxyStrategy = iota
+
+	// ProxyStrategyRandom picks a random proxy of the pool.
+	ProxyStrategyRandom
+)
+
+// proxyPool is a pool of proxy URIs which is safe for concurrent use
+type proxyPool struct {
+	uris     []string
+	strategy ProxyStrategy
+	next     uint32 // index of the next proxy picked by round-robin
+}
+
+// newProxyPool creates a proxyPool with the non-empty uris
+func newProxyPool(uris []string, strategy ProxyStrategy) *proxyPool {
+	p := &proxyPool{strategy: strategy}
+	for _, u := range uris {
+		if u != "" {
+			p.uris = append(p.uris, u)
+		}
+	}
+	return p
+}
+
+// candidates returns the proxies to try in order: the picked proxy first,
+// followed by the rest of the pool as fallbacks. An empty proxy ("") means
+// a direct connection, which is the only candidate when the pool is empty.
+func (p *proxyPool) candidates() []string {
+	if p == nil || len(p.uris) == 0 {
+		return []string{""}
+	}
+
+	n := len(p.uris)
+	var start int
+	switch p.strategy {
+	case ProxyStrategyRandom:
+		start = rand.Intn(n) //nolint:gosec
+	case ProxyStrategyRoundRobin:
+		fallthrough
+	default:
+		start = int((atomic.AddUint32(&p.next, 1) - 1) % uint32(n))
+	}
+
+	ret := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ret = append(ret, p.uris[(start+i)%n])
+	}
+	return ret
+}
+
+// isProxyError reports whether err looks like a failure of the proxy itself
+// (e.g. the proxy refused the connection) rather than of the MX host
+func isProxyError(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		insContains(err.Error(), "socks", "proxy")
+}
+
+// environmentProxy returns the proxy URI of the environment for the MX host, read
+// from ALL_PROXY or HTTPS_PROXY (or their lowercase forms) in that order. It is
+// empty when none is set or when NO_PROXY exempts host, with the rules of net/http.
+func environmentProxy(host string) string {
+	cfg := httpproxy.Config{
+		HTTPSProxy: getenvAny("ALL_PROXY", "all_proxy", "HTTPS_PROXY", "https_proxy"),
+		NoProxy:    getenvAny("NO_PROXY", "no_proxy"),
+	}
+	u, err := cfg.ProxyFunc()(&url.URL{Scheme: "https", Host: host})
+	if err != nil || u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+// isHTTPProxy reports whether the proxy u is an HTTP proxy, which tunnels
+// the connections with the CONNECT method
+func isHTTPProxy(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// dialHTTPProxy connects to addr through a tunnel opened with the CONNECT method of
+// the HTTP proxy u, over TLS when its scheme is https. The credentials of u are sent
+// with the basic authentication scheme. The dial is abandoned when ctx is done.
+func dialHTTPProxy(ctx context.Context, u *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(u.Hostname(), port)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err = req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s: %w", addr, err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s: %w", addr, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s: %s", addr, resp.Status)
+	}
+	// the greeting of the SMTP server may have been read along with the response
+	if r.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn whose reads are served by r first
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Read implements net.Conn
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// getenvAny returns the value of the first environment variable of names which is set
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitBackoff bounds how far the limit of a throttling host is tightened,
+// the limit never drops below the configured limit divided by this factor
+const maxRateLimitBackoff = 64
+
+// hostLimiterMaxHosts is the number of MX hosts whose limiter is kept, the idle
+// limiters are swept and then arbitrary ones evicted beyond it
+const hostLimiterMaxHosts = 10000
+
+// hostLimiter rate limits the connections to each MX host with a token bucket.
+// The limit of a host is halved every time the host throttles us and doubled
+// back towards the configured limit after each successful check.
+// It is safe for concurrent use.
+type hostLimiter struct {
+	limit rate.Limit // configured limit of every host
+	burst int        // configured burst of every host
+
+	mu    sync.Mutex
+	hosts map[string]*rate.Limiter
+}
+
+// newHostLimiter creates a hostLimiter allowing limit connections per second
+// to each host with bursts of at most burst connections
+func newHostLimiter(limit rate.Limit, burst int) *hostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &hostLimiter{
+		limit: limit,
+		burst: burst,
+		hosts: map[string]*rate.Limiter{},
+	}
+}
+
+// limiterOf returns the limiter of host, creating it on first use
+func (l *hostLimiter) limiterOf(host string) *rate.Limiter {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lim, ok := l.hosts[host]
+	if !ok {
+		if len(l.hosts) >= hostLimiterMaxHosts {
+			l.sweep()
+		}
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.hosts[host] = lim
+	}
+	return lim
+}
+
+// sweep deletes the idle limiters, back at the configured limit with a full bucket, which
+// a new limiter replaces without loss. Arbitrary ones are then deleted until there is room
+// for another host, forgetting their throttling. l.mu must be held.
+func (l *hostLimiter) sweep() {
+	now := time.Now()
+	for host, lim := range l.hosts {
+		if lim.Limit() == l.limit && lim.TokensAt(now) >= float64(l.burst) {
+			delete(l.hosts, host)
+		}
+	}
+	for host := range l.hosts {
+		if len(l.hosts) < hostLimiterMaxHosts {
+			break
+		}
+		delete(l.hosts, host)
+	}
+}
+
+// wait blocks until a connection to host is allowed or ctx is done.
+// A nil limiter never blocks.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return l.limiterOf(host).Wait(ctx)
+}
+
+// tighten halves the limit of host after it throttled us
+func (l *hostLimiter) tighten(host string) {
+	if l == nil {
+		return
+	}
+	lim := l.limiterOf(host)
+	next := lim.Limit() / 2
+	if floor := l.limit / maxRateLimitBackoff; next < floor {
+		next = floor
+	}
+	lim.SetLimit(next)
+}
+
+// relax doubles the limit of host after a successful check, up to the configured limit
+func (l *hostLimiter) relax(host string) {
+	if l == nil {
+		return
+	}
+	lim := l.limiterOf(host)
+	if current := lim.Limit(); current < l.limit {
+		lim.SetLimit(min(current*2, l.limit))
+	}
+}
+
+// isThrottled reports whether err means the host throttles us,
+// i.e. a 421 reply or a "too many connections" complaint
+func isThrottled(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	var lookupErr *LookupError
+	if errors.As(err, &lookupErr) {
+		msg = lookupErr.Details
+	}
+	return strings.HasPrefix(msg, "421") || insContains(msg, "too many connections")
+}
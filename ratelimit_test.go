@@ -0,0 +1,113 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestHostLimiter_TightenAndRelax(t *testing.T) {
+	l := newHostLimiter(8, 1)
+
+	l.tighten("mx.example.com.")
+	assert.Equal(t, rate.Limit(4), l.limiterOf("MX.example.com").Limit())
+
+	for i := 0; i < 10; i++ {
+		l.tighten("mx.example.com")
+	}
+	assert.Equal(t, rate.Limit(8)/maxRateLimitBackoff, l.limiterOf("mx.example.com").Limit())
+
+	l.relax("mx.example.com")
+	assert.Equal(t, rate.Limit(8)/maxRateLimitBackoff*2, l.limiterOf("mx.example.com").Limit())
+	for i := 0; i < 10; i++ {
+		l.relax("mx.example.com")
+	}
+	assert.Equal(t, rate.Limit(8), l.limiterOf("mx.example.com").Limit())
+
+	// other hosts aren't affected
+	assert.Equal(t, rate.Limit(8), l.limiterOf("mx.other.com").Limit())
+}
+
+func TestHostLimiter_Wait(t *testing.T) {
+	var l *hostLimiter
+	assert.NoError(t, l.wait(context.Background(), "mx.example.com"))
+
+	l = newHostLimiter(rate.Every(time.Hour), 1)
+	assert.NoError(t, l.wait(context.Background(), "mx.example.com"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, l.wait(ctx, "mx.example.com"))
+}
+
+func TestHostLimiter_Bounded(t *testing.T) {
+	l := newHostLimiter(rate.Every(time.Hour), 1)
+	l.tighten("throttled.example.com")
+	assert.NoError(t, l.wait(context.Background(), "busy.example.com"))
+	for i := 0; len(l.hosts) < hostLimiterMaxHosts; i++ {
+		l.limiterOf(fmt.Sprintf("mx%d.example.com", i))
+	}
+
+	// the idle limiters are evicted, the ones keeping a state are kept
+	l.limiterOf("new.example.com")
+	assert.Len(t, l.hosts, 3)
+	assert.Equal(t, rate.Every(time.Hour)/2, l.limiterOf("throttled.example.com").Limit())
+	assert.Less(t, l.limiterOf("busy.example.com").Tokens(), 1.0)
+
+	// without idle limiters, arbitrary ones make room
+	for i := 0; len(l.hosts) < hostLimiterMaxHosts; i++ {
+		assert.NoError(t, l.wait(context.Background(), fmt.Sprintf("mx%d.example.com", i)))
+	}
+	l.limiterOf("other.example.com")
+	assert.Len(t, l.hosts, hostLimiterMaxHosts)
+}
+
+func TestIsThrottled(t *testing.T) {
+	assert.False(t, isThrottled(nil))
+	assert.True(t, isThrottled(&textproto.Error{Code: 421, Msg: "4.7.0 Try again later"}))
+	assert.True(t, isThrottled(ParseSMTPError(errors.New("421 4.7.0 Try again later"))))
+	assert.True(t, isThrottled(errors.New("too many connections from your IP")))
+	assert.False(t, isThrottled(ParseSMTPError(errors.New("550 5.1.1 user unknown"))))
+}
+
+func TestCheckSMTP_RateLimitTightensOnThrottle(t *testing.T) {
+	defer useFakeSMTPServer(t, func(cmd string) string {
+		if strings.HasPrefix(cmd, "MAIL") {
+			return "421 4.7.0 Too many connections, try again later"
+		}
+		return ""
+	})()
+
+	v := NewVerifier().EnableSMTPCheck().RateLimit(100, 10)
+	_, err := v.CheckSMTP("example.com", "user")
+	assert.Error(t, err)
+	assert.Equal(t, rate.Limit(50), v.limiter.limiterOf("mx.example.com").Limit())
+}
+
+func TestCheckSMTP_RateLimitConcurrent(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+
+	v := NewVerifier().EnableSMTPCheck().RateLimit(1000, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret, err := v.CheckSMTP("example.com", "user")
+			assert.NoError(t, err)
+			assert.True(t, ret.Deliverable)
+		}()
+	}
+	wg.Wait()
+
+	v.RateLimit(0, 0)
+	assert.Nil(t, v.limiter)
+}
@@ -0,0 +1,196 @@
+package emailverifier
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// submissionPort is the port of a submission relay given without one, see SubmissionRelay
+const submissionPort = "587"
+
+// submissionRelay is an authenticated submission server checking the mailboxes of
+// some domains on behalf of the verifier, see SubmissionRelay
+type submissionRelay struct {
+	addr string // "host:port" of the relay
+	user string
+	pass string
+}
+
+// relayTLSConfig returns the TLS configuration of the STARTTLS with the relay host,
+// whose certificate is verified
+var relayTLSConfig = func(host string) *tls.Config {
+	return &tls.Config{ServerName: host}
+}
+
+// SubmissionRelay checks the mailboxes of domains and their subdomains through the
+// submission server at addr ("host:port", port 587 when omitted), for environments where
+// the MX hosts can't be reached on port 25. The relay is greeted, the connection is
+// upgraded with STARTTLS and the verifier authenticates as user with AUTH PLAIN, or
+// LOGIN when the relay doesn't offer PLAIN, before sending the MAIL and RCPT commands:
+// the relay checks the recipient, e.g. by a callout to its MX hosts, and its reply is
+// read like the one of an MX host. Credentials are never sent before TLS is established
+// nor recorded in the debug transcript, which stops at STARTTLS. The catch-all probe
+// isn't sent through the relay. Without domains, the relay checks every domain. The
+// registered mailbox checkers and the API verifiers come first and the relay is skipped
+// in MX-only mode. An empty addr removes the relay of domains, or of every domain.
+func (v *Verifier) SubmissionRelay(addr, user, pass string, domains ...string) *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var relay *submissionRelay
+	if addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, submissionPort)
+		}
+		relay = &submissionRelay{addr: addr, user: user, pass: pass}
+	}
+	if len(domains) == 0 {
+		v.relayFallback = relay
+		return v
+	}
+	v.relays = maps.Clone(v.relays)
+	if v.relays == nil {
+		v.relays = map[string]*submissionRelay{}
+	}
+	for _, domain := range cleanDomains(domains) {
+		if relay == nil {
+			delete(v.relays, domain)
+		} else {
+			v.relays[domain] = relay
+		}
+	}
+	return v
+}
+
+// relayOf returns the submission relay of domain or one of its parent domains,
+// or the relay of every domain, nil when the domain is checked on its MX hosts
+func (v *Verifier) relayOf(domain string) *submissionRelay {
+	if len(v.relays) > 0 {
+		for d := cleanDomain(domain); strings.Contains(d, "."); d = parentDomain(d) {
+			if relay, ok := v.relays[d]; ok {
+				return relay
+			}
+		}
+	}
+	return v.relayFallback
+}
+
+// checkSMTPByRelay performs the SMTP check of the mailbox of username through relay
+func (v *Verifier) checkSMTPByRelay(ctx context.Context, relay *submissionRelay, domain, username string, overrides SMTPOptions) (*SMTP, error) {
+	opts := v.smtpDialOptions(ctx, domain).with(overrides)
+	// an authenticated session isn't shared with the checks of the MX hosts
+	opts.pool = nil
+	host, _, _ := net.SplitHostPort(relay.addr)
+
+	dialStart := time.Now()
+	client, err := v.dialAddr(relay.addr, opts)
+	v.observer.OnSMTPDial(host, time.Since(dialStart), err)
+	if err != nil {
+		return &SMTP{CatchAllStatus: CatchAllUnknown, Transcript: opts.transcript.linesOf("")}, ParseSMTPError(withStage(SMTPStageConnect, err))
+	}
+	defer quitSMTPClient(client)
+	stop := context.AfterFunc(ctx, func() { _ = client.Close() })
+	defer stop()
+
+	ret := SMTP{CatchAllStatus: CatchAllUnknown, Timings: opts.timings.of(client)}
+	// the transcript ends with the notes of the encrypted conversation
+	defer func() { ret.Transcript = opts.transcript.linesOf(host) }()
+	if err = v.timedHello(client, opts, ret.Timings); err != nil {
+		return &ret, ParseSMTPError(withStage(SMTPStageEHLO, err))
+	}
+	ret.Extensions = extensions(client)
+	if err = authenticateRelay(client, host, relay, opts.transcript, ret.Timings); err != nil {
+		return &ret, ParseSMTPError(withStage(SMTPStageAuth, err))
+	}
+	v.logger.Debug("authenticated with the submission relay", "relay", relay.addr, "domain", domain)
+	if err = v.mail(client, opts, ret.Timings); err != nil {
+		return &ret, ParseSMTPError(err)
+	}
+	ret.HostExists = true
+
+	if username != "" {
+		email := fmt.Sprintf("%s@%s", username, domain)
+		if err = checkMailbox(client, email, nil, &ret); err != nil {
+			return &ret, err
+		}
+		reply := "accepted"
+		if ret.Error != nil {
+			reply = ret.Error.Details
+		}
+		opts.transcript.note("RCPT TO:<" + email + "> through the relay: " + reply)
+	}
+	return &ret, nil
+}
+
+// authenticateRelay upgrades the connection of client to relay host with STARTTLS and
+// authenticates with the credentials of relay, which are redacted in tr. The duration
+// of STARTTLS is recorded in timings when it isn't nil. The client must have greeted
+// the relay.
+func authenticateRelay(client *smtp.Client, host string, relay *submissionRelay, tr *transcript, timings *SMTPTimings) error {
+	for _, secret := range []string{
+		relay.user,
+		relay.pass,
+		base64.StdEncoding.EncodeToString([]byte(relay.user)),
+		base64.StdEncoding.EncodeToString([]byte(relay.pass)),
+		base64.StdEncoding.EncodeToString([]byte("\x00" + relay.user + "\x00" + relay.pass)),
+	} {
+		tr.redact(secret)
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		return errors.New("the submission relay doesn't offer STARTTLS")
+	}
+	start := time.Now()
+	err := client.StartTLS(relayTLSConfig(host))
+	if timings != nil {
+		timings.STARTTLS += time.Since(start)
+	}
+	if err != nil {
+		return err
+	}
+	_, mechanisms := client.Extension("AUTH")
+	tr.note("TLS established with the relay, offering AUTH " + mechanisms)
+	switch mechanisms := strings.Fields(strings.ToUpper(mechanisms)); {
+	case slices.Contains(mechanisms, "PLAIN"):
+		return client.Auth(smtp.PlainAuth("", relay.user, relay.pass, host))
+	case slices.Contains(mechanisms, "LOGIN"):
+		return client.Auth(loginAuth{user: relay.user, pass: relay.pass})
+	}
+	return errors.New("the submission relay offers neither AUTH PLAIN nor LOGIN")
+}
+
+// loginAuth is the AUTH LOGIN mechanism, which net/smtp doesn't implement
+type loginAuth struct {
+	user string
+	pass string
+}
+
+// Start refuses to send the credentials over an unencrypted connection
+func (a loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+// Next answers the "Username:" and "Password:" challenges of the server
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch challenge := strings.ToLower(string(fromServer)); {
+	case strings.HasPrefix(challenge, "user"):
+		return []byte(a.user), nil
+	case strings.HasPrefix(challenge, "pass"):
+		return []byte(a.pass), nil
+	}
+	return nil, fmt.Errorf("unexpected AUTH LOGIN challenge %q", fromServer)
+}
@@ -0,0 +1,229 @@
+package emailverifier
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSubmissionRelay is a submission server offering STARTTLS with cert, then the AUTH
+// mechanisms, accepting the credentials user and pass. RCPT is rejected for the local
+// parts starting with "unknown" and before AUTH. It returns the credentials decoded
+// from the AUTH exchanges.
+func fakeSubmissionRelay(cert tls.Certificate, mechanisms, user, pass string) (func() net.Conn, func() []string) {
+	var mu sync.Mutex
+	var received []string
+	record := func(credentials string) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, credentials)
+	}
+	dial := func() net.Conn {
+		client, server := net.Pipe()
+		go func() {
+			// the pipe is closed rather than the TLS conn, whose close_notify would block on the pipe
+			defer server.Close()
+			var conn net.Conn = server
+			r := bufio.NewReader(conn)
+			write := func(reply string) {
+				_, _ = conn.Write([]byte(reply + "\r\n"))
+			}
+			readLine := func() (string, bool) {
+				line, err := r.ReadString('\n')
+				return strings.TrimRight(line, "\r\n"), err == nil
+			}
+			decode := func(s string) string {
+				b, _ := base64.StdEncoding.DecodeString(s)
+				return string(b)
+			}
+			encrypted, authenticated := false, false
+			write("220 relay.example.net ESMTP")
+			for {
+				cmd, ok := readLine()
+				if !ok {
+					return
+				}
+				switch {
+				case strings.HasPrefix(cmd, "EHLO") && encrypted:
+					write("250-relay.example.net\r\n250 AUTH " + mechanisms)
+				case strings.HasPrefix(cmd, "EHLO"):
+					write("250-relay.example.net\r\n250 STARTTLS")
+				case cmd == "STARTTLS":
+					write("220 Ready to start TLS")
+					conn = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+					r = bufio.NewReader(conn)
+					encrypted = true
+				case strings.HasPrefix(cmd, "AUTH PLAIN "):
+					credentials := decode(strings.TrimPrefix(cmd, "AUTH PLAIN "))
+					record(credentials)
+					authenticated = credentials == "\x00"+user+"\x00"+pass
+				case cmd == "AUTH LOGIN":
+					write("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+					u, _ := readLine()
+					write("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+					p, _ := readLine()
+					record(decode(u) + ":" + decode(p))
+					authenticated = decode(u) == user && decode(p) == pass
+				case strings.HasPrefix(cmd, "RCPT") && !authenticated:
+					write("530 5.7.0 Authentication required")
+					continue
+				case strings.HasPrefix(cmd, "RCPT TO:<unknown"):
+					write("550 5.1.1 Recipient address rejected: User unknown")
+					continue
+				case cmd == "QUIT":
+					write("221 Bye")
+					return
+				}
+				switch {
+				case strings.HasPrefix(cmd, "AUTH") && authenticated:
+					write("235 2.7.0 Authentication successful")
+				case strings.HasPrefix(cmd, "AUTH"):
+					write("535 5.7.8 Authentication credentials invalid")
+				case !strings.HasPrefix(cmd, "EHLO") && cmd != "STARTTLS":
+					write("250 OK")
+				}
+			}
+		}()
+		return client
+	}
+	credentials := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), received...)
+	}
+	return dial, credentials
+}
+
+// useFakeSubmissionRelay routes the SMTP dials to relay, trusting cert, and
+// returns the addresses dialed. The MX host of a domain is its "mx" subdomain.
+func useFakeSubmissionRelay(t *testing.T, cert tls.Certificate, relay func() net.Conn) (func() []string, func()) {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+
+	var mu sync.Mutex
+	var dialed []string
+	originalLookupMX := lookupMX
+	originalDialSMTP := dialSMTPFunc
+	originalTLSConfig := relayTLSConfig
+	lookupMX = func(domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	dialSMTPFunc = func(addr string, opts dialOptions) (*smtp.Client, error) {
+		mu.Lock()
+		dialed = append(dialed, addr)
+		mu.Unlock()
+		host, _, _ := net.SplitHostPort(addr)
+		return newSMTPClientOverConn(relay(), host, opts)
+	}
+	relayTLSConfig = func(host string) *tls.Config {
+		return &tls.Config{ServerName: host, RootCAs: roots}
+	}
+	addrs := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), dialed...)
+	}
+	return addrs, func() {
+		lookupMX = originalLookupMX
+		dialSMTPFunc = originalDialSMTP
+		relayTLSConfig = originalTLSConfig
+	}
+}
+
+func TestVerify_SubmissionRelay(t *testing.T) {
+	defer useFakeMX()()
+	cert := selfSignedCert(t, "relay.example.net", time.Now().Add(time.Hour))
+	relay, credentials := fakeSubmissionRelay(cert, "PLAIN LOGIN", "verifier@example.net", "s3cret-pass")
+	dialed, restore := useFakeSubmissionRelay(t, cert, relay)
+	defer restore()
+
+	v := NewVerifier().EnableSMTPCheck().EnableDebugTranscript().
+		SubmissionRelay("relay.example.net", "verifier@example.net", "s3cret-pass", "example.com")
+	ret, err := v.Verify("user@mail.example.com")
+	assert.NoError(t, err)
+	assert.True(t, ret.SMTP.HostExists)
+	assert.True(t, ret.SMTP.Deliverable)
+	assert.Equal(t, CatchAllUnknown, ret.SMTP.CatchAllStatus)
+	assert.Equal(t, MailboxBackendRelay, ret.ChecksPerformed.MailboxBackend)
+	assert.True(t, ret.ChecksPerformed.MailboxChecked)
+	assert.False(t, ret.ChecksPerformed.CatchAllChecked)
+	assert.Equal(t, []string{"relay.example.net:587"}, dialed())
+	assert.Equal(t, []string{"\x00verifier@example.net\x00s3cret-pass"}, credentials())
+
+	// the credentials are never recorded in the transcript
+	transcript := strings.Join(ret.SMTP.Transcript, "\n")
+	assert.Contains(t, transcript, "C: STARTTLS")
+	assert.Contains(t, transcript, "* RCPT TO:<user@mail.example.com> through the relay: accepted")
+	assert.NotContains(t, transcript, "verifier@example.net")
+	assert.NotContains(t, transcript, "s3cret-pass")
+	assert.NotContains(t, transcript, "C: AUTH")
+
+	ret, err = v.Verify("unknown@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.SMTP.Deliverable)
+	assert.Equal(t, ErrMailboxNotFound, ret.SMTP.Error.Message)
+
+	// the other domains are checked on their MX hosts
+	_, _ = v.Verify("user@example.org")
+	assert.Equal(t, "mx.example.org.:25", dialed()[len(dialed())-1])
+
+	// an empty addr removes the relay
+	_, _ = v.SubmissionRelay("", "", "", "example.com").Verify("user@example.com")
+	assert.Equal(t, "mx.example.com.:25", dialed()[len(dialed())-1])
+}
+
+func TestVerify_SubmissionRelayLogin(t *testing.T) {
+	defer useFakeMX()()
+	cert := selfSignedCert(t, "relay.example.net", time.Now().Add(time.Hour))
+	relay, credentials := fakeSubmissionRelay(cert, "LOGIN", "verifier", "s3cret-pass")
+	_, restore := useFakeSubmissionRelay(t, cert, relay)
+	defer restore()
+
+	v := NewVerifier().EnableSMTPCheck().SubmissionRelay("relay.example.net:2525", "verifier", "s3cret-pass")
+	ret, err := v.Verify("user@example.org")
+	assert.NoError(t, err)
+	assert.True(t, ret.SMTP.Deliverable)
+	assert.Equal(t, []string{"verifier:s3cret-pass"}, credentials())
+
+	// the STARTTLS with the relay is timed
+	ret, err = v.EnableTimings().Verify("user@example.org")
+	assert.NoError(t, err)
+	if assert.NotNil(t, ret.SMTP.Timings) {
+		assert.Positive(t, ret.SMTP.Timings.STARTTLS)
+		assert.Positive(t, ret.SMTP.Timings.EHLO)
+	}
+
+	// invalid credentials fail the check
+	_, err = v.SubmissionRelay("relay.example.net:2525", "verifier", "wrong").Verify("user@example.org")
+	var lookupErr *LookupError
+	if assert.ErrorAs(t, err, &lookupErr) {
+		assert.Contains(t, lookupErr.Details, "Authentication credentials invalid")
+	}
+}
+
+func TestVerify_SubmissionRelayWithoutSTARTTLS(t *testing.T) {
+	respond, commands := recordCommands(rejectRandomRcpt)
+	defer useFakeSMTPServer(t, respond)()
+	defer useFakeMX()()
+
+	ret, err := NewVerifier().EnableSMTPCheck().SubmissionRelay("relay.example.net", "verifier", "s3cret-pass").Verify("user@example.com")
+	assert.Nil(t, ret.SMTP)
+	var lookupErr *LookupError
+	if assert.ErrorAs(t, err, &lookupErr) {
+		assert.Contains(t, lookupErr.Details, "STARTTLS")
+	}
+	for _, cmd := range commands() {
+		assert.NotContains(t, cmd, "AUTH")
+	}
+}
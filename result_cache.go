@@ -0,0 +1,157 @@
+package emailverifier
+
+import (
+	"container/list"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultResultCacheSize is the number of results kept when no size is set by ResultCacheSize
+const defaultResultCacheSize = 10000
+
+// resultCache keeps the results of the verifications of full emails for a while, so
+// verifying the same email again, e.g. from a retry queue, is served from memory. The
+// results which aren't reachable expire sooner as their addresses may come alive.
+// It is bounded by maxSize, the least recently used results are evicted first.
+// All methods are no-ops on a nil cache.
+type resultCache struct {
+	mu          sync.Mutex
+	maxSize     int
+	positiveTTL time.Duration            // how long a reachable result is kept
+	negativeTTL time.Duration            // how long any other result is kept, not cached when <= 0
+	entries     map[string]*list.Element // elements of order keyed by email
+	order       *list.List               // cached results, most recently used first
+}
+
+// resultCacheEntry is a cached result of email
+type resultCacheEntry struct {
+	email   string
+	result  *Result
+	expires time.Time
+}
+
+// newResultCache creates an empty resultCache
+func newResultCache(maxSize int, positiveTTL, negativeTTL time.Duration) *resultCache {
+	return &resultCache{
+		maxSize:     maxSize,
+		positiveTTL: positiveTTL,
+		negativeTTL: negativeTTL,
+		entries:     map[string]*list.Element{},
+		order:       list.New(),
+	}
+}
+
+// get returns the unexpired result cached for email, nil when there is none
+func (c *resultCache) get(email string) *Result {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[email]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if !time.Now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, email)
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	// every hit gets its own copy, which the caller may modify
+	return entry.result.clone()
+}
+
+// put caches a copy of the result of email flagged as Cached, for positiveTTL when
+// it is reachable and negativeTTL otherwise, evicting the least recently used
+// results beyond maxSize
+func (c *resultCache) put(email string, result *Result) {
+	if c == nil {
+		return
+	}
+	ttl := c.negativeTTL
+	if result.Reachable == reachableYes {
+		ttl = c.positiveTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	cached := result.clone()
+	cached.Cached = true
+	entry := &resultCacheEntry{email: email, result: cached, expires: time.Now().Add(ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[email]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[email] = c.order.PushFront(entry)
+	c.evict()
+}
+
+// resize bounds the cache to maxSize results
+func (c *resultCache) resize(maxSize int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = maxSize
+	c.evict()
+}
+
+// evict removes the least recently used results beyond maxSize, c.mu must be held
+func (c *resultCache) evict() {
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultCacheEntry).email)
+	}
+}
+
+// clone returns a deep copy of r, sharing none of its pointers, slices and maps
+func (r *Result) clone() *Result {
+	ret := *r
+	ret.SMTP = r.SMTP.clone()
+	ret.MXRecords = slices.Clone(r.MXRecords)
+	ret.SenderIssues = slices.Clone(r.SenderIssues)
+	if r.Gravatar != nil {
+		gravatar := *r.Gravatar
+		ret.Gravatar = &gravatar
+	}
+	if r.DomainAge != nil {
+		age := *r.DomainAge
+		ret.DomainAge = &age
+	}
+	return &ret
+}
+
+// clone returns a deep copy of s, nil when s is nil
+func (s *SMTP) clone() *SMTP {
+	if s == nil {
+		return nil
+	}
+	ret := *s
+	ret.Extensions = maps.Clone(s.Extensions)
+	ret.Transcript = slices.Clone(s.Transcript)
+	if s.Error != nil {
+		e := *s.Error
+		ret.Error = &e
+	}
+	if s.MXDiagnostics != nil {
+		diag := *s.MXDiagnostics
+		diag.IPs = slices.Clone(s.MXDiagnostics.IPs)
+		diag.PTR = maps.Clone(s.MXDiagnostics.PTR)
+		ret.MXDiagnostics = &diag
+	}
+	if s.Timings != nil {
+		timings := *s.Timings
+		ret.Timings = &timings
+	}
+	return &ret
+}
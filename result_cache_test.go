@@ -0,0 +1,147 @@
+package emailverifier
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newResultCache(2, time.Hour, time.Hour)
+	c.put("a@example.com", &Result{Email: "a@example.com"})
+	c.put("b@example.com", &Result{Email: "b@example.com"})
+	assert.NotNil(t, c.get("a@example.com"))
+
+	c.put("c@example.com", &Result{Email: "c@example.com"})
+	assert.NotNil(t, c.get("a@example.com"))
+	assert.Nil(t, c.get("b@example.com"))
+	assert.NotNil(t, c.get("c@example.com"))
+
+	c.resize(1)
+	assert.Nil(t, c.get("a@example.com"))
+	assert.NotNil(t, c.get("c@example.com"))
+}
+
+func TestResultCache_NegativeTTL(t *testing.T) {
+	c := newResultCache(10, time.Hour, 20*time.Millisecond)
+	c.put("yes@example.com", &Result{Reachable: reachableYes})
+	c.put("no@example.com", &Result{Reachable: reachableNo})
+	c.put("unknown@example.com", &Result{Reachable: reachableUnknown})
+
+	cached := c.get("no@example.com")
+	if assert.NotNil(t, cached) {
+		assert.True(t, cached.Cached)
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.NotNil(t, c.get("yes@example.com"))
+	assert.Nil(t, c.get("no@example.com"))
+	assert.Nil(t, c.get("unknown@example.com"))
+
+	// a non-positive TTL doesn't cache its results
+	c = newResultCache(10, time.Hour, 0)
+	c.put("no@example.com", &Result{Reachable: reachableNo})
+	assert.Nil(t, c.get("no@example.com"))
+
+	var nilCache *resultCache
+	nilCache.put("yes@example.com", &Result{Reachable: reachableYes})
+	assert.Nil(t, nilCache.get("yes@example.com"))
+}
+
+func TestResultCache_ReturnsCopies(t *testing.T) {
+	c := newResultCache(10, time.Hour, time.Hour)
+	result := &Result{
+		Reachable:    reachableYes,
+		SMTP:         &SMTP{Deliverable: true, Extensions: map[string]string{"SIZE": "1000"}, Error: &LookupError{Message: ErrFullInbox}},
+		MXRecords:    []MXRecord{{Host: "mx.example.com.", Pref: 10}},
+		SenderIssues: []string{"issue"},
+	}
+	c.put("user@example.com", result)
+	// the caller keeps using its result after caching it
+	result.SMTP.Deliverable = false
+
+	hit := c.get("user@example.com")
+	hit.SMTP.Deliverable = false
+	hit.SMTP.Extensions["SIZE"] = "1"
+	hit.SMTP.Error.Message = ErrMailboxNotFound
+	hit.MXRecords[0].Host = "changed."
+	hit.SenderIssues[0] = "changed"
+
+	next := c.get("user@example.com")
+	assert.NotSame(t, hit, next)
+	assert.True(t, next.SMTP.Deliverable)
+	assert.Equal(t, "1000", next.SMTP.Extensions["SIZE"])
+	assert.Equal(t, ErrFullInbox, next.SMTP.Error.Message)
+	assert.Equal(t, "mx.example.com.", next.MXRecords[0].Host)
+	assert.Equal(t, []string{"issue"}, next.SenderIssues)
+	assert.False(t, result.Cached)
+}
+
+func TestVerify_ResultCache(t *testing.T) {
+	defer useFakeMX()()
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+
+	v := NewVerifier().EnableSMTPCheck().EnableResultCache(time.Hour, time.Hour)
+	ret, err := v.Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, reachableYes, ret.Reachable)
+	assert.False(t, ret.Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	ret, err = v.Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, reachableYes, ret.Reachable)
+	assert.True(t, ret.Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	_, err = v.Verify("other@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+
+	// enabling the cache again clears it
+	ret, err = v.EnableResultCache(time.Hour, time.Hour).Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.Cached)
+	assert.Equal(t, int32(3), atomic.LoadInt32(dials))
+
+	ret, err = v.DisableResultCache().Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.Cached)
+	assert.Equal(t, int32(4), atomic.LoadInt32(dials))
+}
+
+func TestVerify_ResultCacheKeyedByNormalizedEmail(t *testing.T) {
+	defer useFakeMX()()
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+	obs := &recordingObserver{}
+
+	v := NewVerifier().EnableSMTPCheck().EnableResultCache(time.Hour, time.Hour).WithObserver(obs)
+	ret, err := v.Verify(" user@Example.com")
+	assert.NoError(t, err)
+	assert.False(t, ret.Cached)
+	assert.Equal(t, "user@example.com", ret.NormalizedEmail)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	ret, err = v.Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ret.Cached)
+	assert.Equal(t, "user@example.com", ret.Email)
+	assert.Empty(t, ret.NormalizedEmail)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	ret, err = v.Verify("user@EXAMPLE.com ")
+	assert.NoError(t, err)
+	assert.True(t, ret.Cached)
+	assert.Equal(t, "user@EXAMPLE.com ", ret.Email)
+	assert.Equal(t, "user@example.com", ret.NormalizedEmail)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	// the observer is notified of the cached verifications too
+	if assert.Len(t, obs.done, 3) {
+		assert.True(t, obs.done[2].Cached)
+		assert.Equal(t, "user@EXAMPLE.com ", obs.done[2].Email)
+	}
+}
@@ -0,0 +1,129 @@
+package emailverifier
+
+// Score weights, the contributions of the factors of ScoreBreakdown
+const (
+	scoreSyntax        = 10  // the address is syntactically valid
+	scoreMX            = 20  // the domain publishes MX records
+	scoreImplicitMX    = 10  // the domain receives mail on its A/AAAA record only
+	scoreDeliverable   = 70  // the mailbox accepted RCPT TO and the host isn't catch-all
+	scoreCatchAll      = 40  // the host accepts any address, the mailbox is unconfirmed (catch-all or accept-all gateway)
+	scoreHostExists    = 20  // the host answered but the mailbox wasn't checked
+	scoreHardBounce    = -80 // the mailbox or the domain doesn't exist or is disabled
+	scoreFullInbox     = -30 // the mailbox exists but can't receive mail
+	scoreDisposable    = -60 // the domain is a disposable email provider
+	scoreRole          = -10 // the address is a role account, e.g. info@
+	scoreFree          = -5  // the domain is a free email provider
+	scoreCatchAllCap   = 60  // highest score of a catch-all host, in the unknown band
+	scoreDisposableCap = 20  // highest score of a disposable address
+	scoreHardBounceCap = 10  // highest score of a hard bounce
+	scoreMax           = 100 // highest score
+)
+
+// ScoreBreakdown is the contribution of each factor to Result.Score, the
+// contributions of the factors which don't apply are zero. Score is the sum of
+// the contributions clamped to [0, Cap].
+//
+// | factor      | condition                                             | points |
+// |-------------|-------------------------------------------------------|--------|
+// | Syntax      | the address is valid, otherwise the score is 0        | +10    |
+// | MX          | the domain has MX records (implicit MX only: +10)     | +20    |
+// | SMTP        | deliverable and not catch-all                         | +70    |
+// |             | catch-all host, Cap is lowered to 60                  | +40    |
+// |             | accept-all gateway, e.g. Microsoft 365, Cap is 60     | +40    |
+// |             | host exists, mailbox not checked                      | +20    |
+// |             | full inbox                                            | -30    |
+// |             | mailbox or host not found or disabled, Cap is 10      | -80    |
+// | Disposable  | disposable domain, Cap is lowered to 20               | -60    |
+// | Role        | role account                                          | -10    |
+// | Free        | free email domain                                     | -5     |
+//
+// Scores from 70 are likely deliverable, scores from 40 to 69 are unknown
+// (e.g. catch-all hosts or mailboxes which weren't checked) and scores below 40
+// are likely to bounce or lack evidence, e.g. without the SMTP check.
+//
+// SPF and DMARC are deliberately not scored: they authenticate the mail the
+// domain sends and say nothing about whether the mailbox receives mail.
+type ScoreBreakdown struct {
+	Syntax     int `json:"syntax"`
+	MX         int `json:"mx"`
+	SMTP       int `json:"smtp"`
+	Disposable int `json:"disposable"`
+	Role       int `json:"role"`
+	Free       int `json:"free"`
+	Cap        int `json:"cap"`   // highest score allowed by the signals, 100 when none lowers it
+	Score      int `json:"score"` // the sum of the contributions clamped to [0, Cap]
+}
+
+// Score returns the deliverability confidence of the address from 0 to 100,
+// see ScoreBreakdown for the weighting
+func (r *Result) Score() int {
+	return r.ScoreBreakdown().Score
+}
+
+// ScoreBreakdown returns the contribution of each factor to Score, so the
+// weighting can be tuned without re-deriving it from the fields of the Result
+func (r *Result) ScoreBreakdown() ScoreBreakdown {
+	b := ScoreBreakdown{Cap: scoreMax}
+	if !r.Syntax.Valid {
+		b.Cap = 0
+		return b
+	}
+	b.Syntax = scoreSyntax
+
+	switch {
+	case r.UsedImplicitMX:
+		// the A/AAAA fallback isn't an MX record, HasMxRecords is false
+		b.MX = scoreImplicitMX
+	case r.HasMxRecords:
+		b.MX = scoreMX
+	}
+
+	if s := r.SMTP; s != nil {
+		switch {
+		case isHardBounce(s):
+			b.SMTP = scoreHardBounce
+			b.Cap = min(b.Cap, scoreHardBounceCap)
+		case s.FullInbox:
+			b.SMTP = scoreFullInbox
+		case s.CatchAllStatus == CatchAllYes, s.AcceptAllThenBounce:
+			// the acceptance of a gateway bouncing later confirms the mailbox no more than a catch-all
+			b.SMTP = scoreCatchAll
+			b.Cap = min(b.Cap, scoreCatchAllCap)
+		case s.Deliverable:
+			b.SMTP = scoreDeliverable
+		case s.HostExists:
+			b.SMTP = scoreHostExists
+		}
+	}
+
+	if r.Disposable {
+		b.Disposable = scoreDisposable
+		b.Cap = min(b.Cap, scoreDisposableCap)
+	}
+	if r.RoleAccount {
+		b.Role = scoreRole
+	}
+	if r.Free {
+		b.Free = scoreFree
+	}
+
+	sum := b.Syntax + b.MX + b.SMTP + b.Disposable + b.Role + b.Free
+	b.Score = max(0, min(sum, b.Cap))
+	return b
+}
+
+// isHardBounce tells whether the SMTP check found that the mailbox or its host doesn't
+// exist or is disabled, so a message to the address would bounce
+func isHardBounce(s *SMTP) bool {
+	if s.Disabled {
+		return true
+	}
+	if s.Error == nil || s.Error.Temporary {
+		return false
+	}
+	switch s.Error.Message {
+	case ErrMailboxNotFound, ErrMailboxDisabled, ErrNoSuchHost, ErrRCPTHasMoved:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult_Score(t *testing.T) {
+	valid := Syntax{Username: "user", Domain: "example.com", Valid: true}
+	cases := []struct {
+		name   string
+		result Result
+		want   ScoreBreakdown
+	}{
+		{
+			name:   "invalid syntax",
+			result: Result{HasMxRecords: true, SMTP: &SMTP{HostExists: true, Deliverable: true}},
+			want:   ScoreBreakdown{},
+		},
+		{
+			name:   "deliverable",
+			result: Result{Syntax: valid, HasMxRecords: true, SMTP: &SMTP{HostExists: true, Deliverable: true, CatchAllStatus: CatchAllNo}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: 70, Cap: 100, Score: 100},
+		},
+		{
+			name:   "deliverable free role account",
+			result: Result{Syntax: valid, HasMxRecords: true, Free: true, RoleAccount: true, SMTP: &SMTP{HostExists: true, Deliverable: true}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: 70, Role: -10, Free: -5, Cap: 100, Score: 85},
+		},
+		{
+			name:   "catch-all",
+			result: Result{Syntax: valid, HasMxRecords: true, SMTP: &SMTP{HostExists: true, Deliverable: true, CatchAll: true, CatchAllStatus: CatchAllYes}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: 40, Cap: 60, Score: 60},
+		},
+		{
+			name:   "accepted by a gateway accepting all",
+			result: Result{Syntax: valid, HasMxRecords: true, SMTP: &SMTP{HostExists: true, Deliverable: true, CatchAllStatus: CatchAllUnknown, AcceptAllThenBounce: true, Provider: ProviderMicrosoft365}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: 40, Cap: 60, Score: 60},
+		},
+		{
+			name:   "mailbox not found",
+			result: Result{Syntax: valid, HasMxRecords: true, SMTP: &SMTP{HostExists: true, Error: newLookupError(ErrMailboxNotFound, "")}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: -80, Cap: 10, Score: 0},
+		},
+		{
+			name:   "disposable",
+			result: Result{Syntax: valid, HasMxRecords: true, Disposable: true, SMTP: &SMTP{HostExists: true, Deliverable: true}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: 70, Disposable: -60, Cap: 20, Score: 20},
+		},
+		{
+			name:   "mailbox not checked",
+			result: Result{Syntax: valid, HasMxRecords: true, SMTP: &SMTP{HostExists: true, MailboxCheckSkipped: true}},
+			want:   ScoreBreakdown{Syntax: 10, MX: 20, SMTP: 20, Cap: 100, Score: 50},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, c.result.ScoreBreakdown())
+			assert.Equal(t, c.want.Score, c.result.Score())
+		})
+	}
+}
+
+func TestVerify_ScoreImplicitMX(t *testing.T) {
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	defer stubLookupHost(map[string][]string{"example.com": {"192.0.2.1"}})()
+
+	ret, err := NewVerifier().DisableFreeCheck().Verify("jane.doe@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ret.UsedImplicitMX)
+	assert.False(t, ret.HasMxRecords)
+	assert.Equal(t, ScoreBreakdown{Syntax: 10, MX: 10, Cap: 100, Score: 20}, ret.ScoreBreakdown())
+}
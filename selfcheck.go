@@ -0,0 +1,110 @@
+package emailverifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SelfCheckResult is the health of the sending identity, the domain of FromEmail,
+// as checked by SelfCheck. It says nothing about the recipients.
+type SelfCheckResult struct {
+	Domain       string          `json:"domain"`                 // domain of FromEmail
+	HasMX        bool            `json:"has_mx"`                 // whether the domain publishes MX records, so it can receive replies and bounces
+	SPF          string          `json:"spf,omitempty"`          // SPF record of the domain, empty when it has none or several
+	DMARC        string          `json:"dmarc,omitempty"`        // DMARC record of the domain, empty when it has none or several
+	DMARCPolicy  string          `json:"dmarc_policy,omitempty"` // p= tag of the DMARC record, e.g. "reject"
+	SPFRecords   *TXTPolicy      `json:"spf_records"`            // SPF records of the domain as served, see CheckSPF
+	DMARCRecords *TXTPolicy      `json:"dmarc_records"`          // DMARC records of the domain as served, see CheckDMARC
+	Blocklists   map[string]bool `json:"blocklists"`             // whether each zone of DefaultDomainDNSBLZones lists the domain
+	Issues       []string        `json:"issues"`                 // problems found, empty when the domain looks healthy
+}
+
+// Healthy reports whether the self check found no issue
+func (r *SelfCheckResult) Healthy() bool {
+	return len(r.Issues) == 0
+}
+
+// SelfCheck is a pre-flight check of the sending identity rather than of a recipient:
+// it checks that the domain of FromEmail has MX records, an SPF record without "+all"
+// and a DMARC record enforcing a policy, and that it isn't listed by the zones of
+// DefaultDomainDNSBLZones. The problems are summarized in SelfCheckResult.Issues,
+// a blocklist which can't be queried (e.g. Spamhaus refuses public resolvers) is an
+// issue too. An error is returned when FromEmail has no domain or DNS can't be queried.
+func (v *Verifier) SelfCheck() (*SelfCheckResult, error) {
+	v = v.snapshot()
+	return v.selfCheck(context.Background())
+}
+
+// selfCheck is SelfCheck bound to ctx
+func (v *Verifier) selfCheck(ctx context.Context) (*SelfCheckResult, error) {
+	syntax := v.ParseAddress(v.fromEmail)
+	if !syntax.Valid {
+		return nil, fmt.Errorf("invalid from email %q", v.fromEmail)
+	}
+	domain := cleanDomain(syntax.Domain)
+	ret := &SelfCheckResult{Domain: domain}
+
+	mx, err := v.checkMX(ctx, domain)
+	switch {
+	case err != nil && !isNotFound(err):
+		return nil, err
+	case err != nil:
+		ret.issue("the domain has no MX or A/AAAA record, replies and bounces can't be delivered")
+	case mx.NullMX:
+		ret.issue("the domain publishes a null MX, replies and bounces can't be delivered")
+	case mx.ImplicitMX:
+		ret.issue("the domain has no MX record, replies and bounces rely on its A/AAAA record")
+	default:
+		ret.HasMX = true
+	}
+
+	ret.SPFRecords, err = v.checkTXTPolicy(ctx, domain, "v=spf1")
+	if err != nil {
+		return nil, err
+	}
+	switch spf := ret.SPFRecords; {
+	case spf.Multiple:
+		ret.issue("the domain has several SPF records, which is an SPF permerror")
+	case spf.Record == "":
+		ret.issue("the domain has no SPF record")
+	default:
+		ret.SPF = spf.Record
+		if fields := strings.Fields(ret.SPF); fields[len(fields)-1] == "+all" || fields[len(fields)-1] == "all" {
+			ret.issue("the SPF record ends with \"+all\", any host may send as the domain")
+		}
+	}
+
+	ret.DMARCRecords, err = v.checkTXTPolicy(ctx, "_dmarc."+domain, "v=DMARC1")
+	if err != nil {
+		return nil, err
+	}
+	switch dmarc := ret.DMARCRecords; {
+	case dmarc.Multiple:
+		ret.issue("the domain has several DMARC records, which disables DMARC")
+	case dmarc.Record == "":
+		ret.issue("the domain has no DMARC record")
+	default:
+		ret.DMARC = dmarc.Record
+		ret.DMARCPolicy = dmarcPolicy(ret.DMARC)
+		if ret.DMARCPolicy == "" || ret.DMARCPolicy == "none" {
+			ret.issue("the DMARC policy doesn't enforce quarantine or reject")
+		}
+	}
+
+	ret.Blocklists, err = checkDNSBL(ctx, domain, DefaultDomainDNSBLZones)
+	if err != nil {
+		ret.issue(fmt.Sprintf("the blocklists couldn't be queried: %v", err))
+	}
+	for _, zone := range DefaultDomainDNSBLZones {
+		if zone = strings.Trim(zone, "."); ret.Blocklists[zone] {
+			ret.issue("the domain is listed by " + zone)
+		}
+	}
+	return ret, nil
+}
+
+// issue records a problem found by the self check
+func (r *SelfCheckResult) issue(problem string) {
+	r.Issues = append(r.Issues, problem)
+}
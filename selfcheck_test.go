@@ -0,0 +1,103 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubSenderDNS answers the MX lookups with mx and the host lookups with the
+// addresses of listed, e.g. the DNSBL query names, other hosts are not found
+func stubSenderDNS(mx []*net.MX, listed map[string][]string) func() {
+	originalLookupMX := lookupMXContext
+	originalLookupHost := lookupHostContext
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		if len(mx) == 0 {
+			return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+		}
+		return mx, nil
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		if addrs, ok := listed[host]; ok {
+			return addrs, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return func() {
+		lookupMXContext = originalLookupMX
+		lookupHostContext = originalLookupHost
+	}
+}
+
+func TestSelfCheck_Healthy(t *testing.T) {
+	defer stubSenderDNS([]*net.MX{{Host: "mx.sender.example.", Pref: 10}}, nil)()
+	defer stubLookupTXT(map[string][]string{
+		"sender.example":        {"google-site-verification=abc", "v=spf1 include:_spf.google.com ~all"},
+		"_dmarc.sender.example": {"v=DMARC1; p=reject; rua=mailto:dmarc@sender.example"},
+	})()
+
+	ret, err := NewVerifier().FromEmail("news@Sender.example").SelfCheck()
+	assert.NoError(t, err)
+	assert.Equal(t, "sender.example", ret.Domain)
+	assert.True(t, ret.HasMX)
+	assert.Equal(t, "v=spf1 include:_spf.google.com ~all", ret.SPF)
+	assert.Equal(t, "reject", ret.DMARCPolicy)
+	assert.Equal(t, map[string]bool{"dbl.spamhaus.org": false}, ret.Blocklists)
+	assert.Empty(t, ret.Issues)
+	assert.True(t, ret.Healthy())
+}
+
+func TestSelfCheck_Issues(t *testing.T) {
+	defer stubSenderDNS(nil, map[string][]string{
+		"sender.example":                  {"192.0.2.1"},
+		"sender.example.dbl.spamhaus.org": {"127.0.1.2"},
+	})()
+	defer stubLookupTXT(map[string][]string{
+		"sender.example":        {"v=spf1 +all"},
+		"_dmarc.sender.example": {"v=DMARC1; p=none"},
+	})()
+
+	ret, err := NewVerifier().FromEmail("news@sender.example").SelfCheck()
+	assert.NoError(t, err)
+	assert.False(t, ret.HasMX)
+	assert.False(t, ret.Healthy())
+	assert.Len(t, ret.Issues, 4)
+	for i, want := range []string{"no MX record", "+all", "DMARC policy", "listed by dbl.spamhaus.org"} {
+		assert.True(t, strings.Contains(ret.Issues[i], want), ret.Issues[i])
+	}
+}
+
+func TestSelfCheck_MissingRecords(t *testing.T) {
+	defer stubSenderDNS([]*net.MX{{Host: "mx.sender.example.", Pref: 10}}, nil)()
+	defer stubLookupTXT(nil)()
+
+	ret, err := NewVerifier().FromEmail("news@sender.example").SelfCheck()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"the domain has no SPF record", "the domain has no DMARC record"}, ret.Issues)
+
+	_, err = NewVerifier().FromEmail("not an email").SelfCheck()
+	assert.Error(t, err)
+}
+
+func TestSelfCheck_MultipleRecords(t *testing.T) {
+	defer stubSenderDNS([]*net.MX{{Host: "mx.sender.example.", Pref: 10}}, nil)()
+	defer stubLookupTXT(map[string][]string{
+		"sender.example":        {"v=spf1 mx -all", "v=spf1 include:_spf.google.com ~all"},
+		"_dmarc.sender.example": {"v=DMARC1; p=reject", "v=DMARC1; p=none"},
+	})()
+
+	ret, err := NewVerifier().FromEmail("news@sender.example").EnableRawTXTRecords().SelfCheck()
+	assert.NoError(t, err)
+	assert.Empty(t, ret.SPF)
+	assert.True(t, ret.SPFRecords.Multiple)
+	assert.Equal(t, []string{"v=spf1 mx -all", "v=spf1 include:_spf.google.com ~all"}, ret.SPFRecords.TXT)
+	assert.Empty(t, ret.DMARC)
+	assert.True(t, ret.DMARCRecords.Multiple)
+	assert.Equal(t, []string{
+		"the domain has several SPF records, which is an SPF permerror",
+		"the domain has several DMARC records, which disables DMARC",
+	}, ret.Issues)
+}
@@ -0,0 +1,160 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// senderCheckTTL is how long the issues found with the domain of a sender are reused
+const senderCheckTTL = time.Hour
+
+// senderChecks caches the issues of the domains of the MAIL FROM identities, shared
+// by the snapshots of the verifier. A nil senderChecks checks nothing.
+type senderChecks struct {
+	mu      sync.Mutex
+	domains map[string]senderCheck
+}
+
+// senderCheck is the outcome of the check of the domain of a sender
+type senderCheck struct {
+	issues  []string
+	expires time.Time
+}
+
+// newSenderChecks creates an empty senderChecks
+func newSenderChecks() *senderChecks {
+	return &senderChecks{domains: map[string]senderCheck{}}
+}
+
+// get returns the issues of domain, ok is false when it wasn't checked or expired
+func (c *senderChecks) get(domain string) (issues []string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	check, ok := c.domains[domain]
+	if !ok || time.Now().After(check.expires) {
+		delete(c.domains, domain)
+		return nil, false
+	}
+	return check.issues, true
+}
+
+// put records the issues of domain
+func (c *senderChecks) put(domain string, issues []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.domains[domain] = senderCheck{issues: issues, expires: time.Now().Add(senderCheckTTL)}
+}
+
+// EnableSenderCheck checks the domain of the MAIL FROM identity of Verify, i.e. FromEmail
+// or SMTPOptions.FromEmail, for MX and SPF records. Recipients checking the sender may
+// reject the probe of a domain without them, which reads as ErrBlocked or as a missing
+// mailbox, so the problems are recorded in Result.SenderIssues and logged as a warning.
+// Each domain is checked once an hour, its lookup failing records no issue. The null
+// sender isn't checked. Disabled by default, see SelfCheck for a full report.
+func (v *Verifier) EnableSenderCheck() *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.senderChecks == nil {
+		v.senderChecks = newSenderChecks()
+	}
+	return v
+}
+
+// DisableSenderCheck stops checking the domain of the MAIL FROM identity, see EnableSenderCheck
+func (v *Verifier) DisableSenderCheck() *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.senderChecks = nil
+	return v
+}
+
+// mailFrom returns the MAIL FROM identity of the SMTP check of Verify, empty for the null sender
+func (v *Verifier) mailFrom() string {
+	switch {
+	case v.smtpOverrides.FromEmail != "":
+		return v.smtpOverrides.FromEmail
+	case v.nullSender:
+		return ""
+	}
+	return v.fromEmail
+}
+
+// senderIssues returns the problems of the domain of the sender from which may get
+// the probe rejected, nil when the sender check is disabled or from is the null sender
+func (v *Verifier) senderIssues(ctx context.Context, from string) []string {
+	if v.senderChecks == nil || from == "" {
+		return nil
+	}
+	syntax := v.ParseAddress(from)
+	if !syntax.Valid {
+		return []string{"the sender " + from + " is not a valid address"}
+	}
+	domain := cleanDomain(syntax.Domain)
+	if issues, ok := v.senderChecks.get(domain); ok {
+		return issues
+	}
+
+	var issues []string
+	mx, err := v.checkMX(ctx, domain)
+	switch {
+	case err != nil && !isNotFound(err):
+		return nil
+	case err != nil:
+		issues = append(issues, "the domain of the sender "+domain+" has no MX or A/AAAA record")
+	case mx.NullMX:
+		issues = append(issues, "the domain of the sender "+domain+" publishes a null MX")
+	case mx.ImplicitMX:
+		issues = append(issues, "the domain of the sender "+domain+" has no MX record")
+	}
+	spf, err := v.checkTXTPolicy(ctx, domain, "v=spf1")
+	switch {
+	case err != nil:
+		return nil
+	case spf.Multiple:
+		issues = append(issues, "the domain of the sender "+domain+" has several SPF records")
+	case spf.Record == "":
+		issues = append(issues, "the domain of the sender "+domain+" has no SPF record")
+	}
+
+	v.senderChecks.put(domain, issues)
+	if len(issues) > 0 {
+		v.logger.Warn("recipients may reject the probe of the sender", "from", from, "issues", strings.Join(issues, "; "))
+	}
+	return issues
+}
+
+// FromEmailForIP returns the first of candidates whose domain matches the reverse DNS of
+// ip, the IP the verifier connects from (e.g. the egress IP of its proxy): the domain is
+// one of the PTR names of ip or a parent of it, e.g. "verify@example.com" matches the PTR
+// "mail.example.com". A MAIL FROM aligned with the connecting host is less likely to be
+// rejected, pass it to FromEmail or SMTPOptions.FromEmail. It is empty when no candidate
+// matches or ip has no PTR record, see CheckFCrDNS for the forward confirmation.
+func FromEmailForIP(ip net.IP, candidates ...string) (string, error) {
+	if ip == nil || ip.To16() == nil {
+		return "", errInvalidIP
+	}
+	names, err := lookupAddrContext(context.Background(), ip.String())
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		i := strings.LastIndex(candidate, "@")
+		if i < 0 {
+			continue
+		}
+		domain := cleanDomain(candidate[i+1:])
+		for _, name := range names {
+			name = cleanDomain(name)
+			if name == domain || strings.HasSuffix(name, "."+domain) {
+				return candidate, nil
+			}
+		}
+	}
+	return "", nil
+}
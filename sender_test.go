@@ -0,0 +1,77 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_SenderIssues(t *testing.T) {
+	respond, commands := recordCommands(rejectRandomRcpt)
+	defer useFakeSMTPServer(t, respond)()
+	defer useFakeMX()()
+	defer stubLookupTXT(map[string][]string{
+		"aligned.example": {"v=spf1 ip4:192.0.2.0/24 -all"},
+	})()
+
+	v := NewVerifier().EnableSMTPCheck().EnableSenderCheck().FromEmail("probe@Unaligned.example")
+	ret, err := v.Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"the domain of the sender unaligned.example has no SPF record"}, ret.SenderIssues)
+	assert.True(t, ret.SMTP.Deliverable)
+
+	// the MAIL FROM identity can be set for a single verification
+	ret, err = v.VerifyWithOptions("user@example.com", SMTPOptions{FromEmail: "probe@aligned.example"})
+	assert.NoError(t, err)
+	assert.Empty(t, ret.SenderIssues)
+	assert.Contains(t, commands(), "MAIL FROM:<probe@aligned.example> BODY=8BITMIME")
+
+	ret, err = v.Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, ret.SenderIssues, 1)
+
+	ret, err = v.DisableSenderCheck().Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.SenderIssues)
+
+	// the null sender isn't checked
+	ret, err = v.EnableSenderCheck().UseNullSender(true).Verify("user@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.SenderIssues)
+}
+
+func TestFromEmailForIP(t *testing.T) {
+	original := lookupAddrContext
+	defer func() { lookupAddrContext = original }()
+	lookupAddrContext = func(ctx context.Context, addr string) ([]string, error) {
+		switch addr {
+		case "192.0.2.1":
+			return []string{"mail.Example.com."}, nil
+		case "192.0.2.9":
+			return nil, errors.New("server misbehaving")
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+
+	candidates := []string{"invalid", "verify@other.example", "verify@example.com", "probe@mail.example.com"}
+	from, err := FromEmailForIP(net.ParseIP("192.0.2.1"), candidates...)
+	assert.NoError(t, err)
+	assert.Equal(t, "verify@example.com", from)
+
+	from, err = FromEmailForIP(net.ParseIP("192.0.2.1"), "verify@ample.com")
+	assert.NoError(t, err)
+	assert.Empty(t, from)
+
+	from, err = FromEmailForIP(net.ParseIP("192.0.2.2"), candidates...)
+	assert.NoError(t, err)
+	assert.Empty(t, from)
+
+	_, err = FromEmailForIP(net.ParseIP("192.0.2.9"), candidates...)
+	assert.Error(t, err)
+
+	_, err = FromEmailForIP(nil, candidates...)
+	assert.Error(t, err)
+}
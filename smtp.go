@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/smtp"
+	"net/textproto"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	"golang.org/x/net/proxy"
@@ -19,112 +23,755 @@ var lookupMX = net.LookupMX
 
 var dialSMTPFunc = dialSMTP
 
+// DialFunc connects to the address on the named network, like net.Dialer.DialContext.
+// See WithSMTPDialer.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// CatchAllStatus describes whether a domain has a catch-all email address
+type CatchAllStatus string
+
+const (
+	CatchAllYes     CatchAllStatus = "yes"     // the catch-all probe was accepted
+	CatchAllNo      CatchAllStatus = "no"      // the catch-all probe was rejected
+	CatchAllUnknown CatchAllStatus = "unknown" // the catch-all probe didn't complete
+)
+
+// Commands recorded in SMTP.MailboxCheckMethod
+const (
+	MailboxCheckVRFY = "vrfy" // the server answered VRFY authoritatively
+	MailboxCheckRCPT = "rcpt" // the mailbox was probed with RCPT
+)
+
 // SMTP stores all information for SMTP verification lookup
 type SMTP struct {
-	HostExists  bool `json:"host_exists"` // is the host exists?
-	FullInbox   bool `json:"full_inbox"`  // is the email account's inbox full?
-	CatchAll    bool `json:"catch_all"`   // does the domain have a catch-all email address?
-	Deliverable bool `json:"deliverable"` // can send an email to the email server?
-	Disabled    bool `json:"disabled"`    // is the email blocked or disabled by the provider?
+	HostExists bool `json:"host_exists"` // is the host exists?
+	FullInbox  bool `json:"full_inbox"`  // is the email account's inbox full?
+	// FullInboxPermanent tells that the full inbox was rejected permanently (5xx, e.g. an account
+	// over quota) rather than temporarily (4xx, a mailbox full for now which may accept mail later)
+	FullInboxPermanent bool `json:"full_inbox_permanent,omitempty"`
+	// CatchAll tells whether the domain has a catch-all email address.
+	//
+	// Deprecated: use CatchAllStatus, CatchAll is true when the catch-all check
+	// is disabled and false when the probe didn't complete. It will be removed in a future release.
+	CatchAll       bool           `json:"catch_all"`
+	CatchAllStatus CatchAllStatus `json:"catch_all_status"` // the outcome of the catch-all probe
+	Deliverable    bool           `json:"deliverable"`      // can send an email to the email server?
+	Disabled       bool           `json:"disabled"`         // is the email blocked or disabled by the provider?
+
+	// CatchAllWithRejection tells that the host accepted the catch-all probe but
+	// rejected the checked address itself, see Error for the rejection
+	CatchAllWithRejection bool `json:"catch_all_with_rejection,omitempty"`
+
+	// CatchAllSharedFrom is the domain whose probe on the same MX host gave CatchAllStatus,
+	// when it wasn't probed for this check, see ShareCatchAllByMX
+	CatchAllSharedFrom string `json:"catch_all_shared_from,omitempty"`
+
+	MailboxCheckSkipped bool   `json:"mailbox_check_skipped,omitempty"` // MAIL FROM/RCPT weren't sent, only the host was checked (see EnableMXOnlyMode)
+	MailboxCheckMethod  string `json:"mailbox_check_method,omitempty"`  // command which produced Deliverable, MailboxCheckVRFY or MailboxCheckRCPT, only recorded when EnableVRFY
+	Trusted             bool   `json:"trusted,omitempty"`               // the domain is trusted (see TrustDomains), the result is TrustedDomainVerdict without any check
+
+	// Provider is the mail provider of the MX host, see DetectMailProvider. When the provider
+	// accepts all recipients and bounces later (see ProviderBehavior), an accepted RCPT sets
+	// AcceptAllThenBounce, leaves CatchAllStatus unknown and is explained in Note.
+	Provider            string `json:"provider,omitempty"`
+	AcceptAllThenBounce bool   `json:"accept_all_then_bounce,omitempty"`
+	Note                string `json:"note,omitempty"`
+
+	// Extensions are the well-known extensions (see KnownSMTPExtensions) advertised
+	// in the EHLO reply of the server, keyed by name with their parameters, e.g.
+	// "SIZE": "35882577". It is empty when the server only speaks HELO.
+	Extensions map[string]string `json:"extensions,omitempty"`
+
+	// Error is the classified rejection of the mailbox when Deliverable is false,
+	// e.g. ErrMailboxNotFound, ErrFullInbox or ErrMailboxDisabled. Server problems
+	// such as greylisting or timeouts are returned as the error of the check instead.
+	Error *LookupError `json:"error,omitempty"`
+
+	Transcript []string `json:"transcript,omitempty"` // SMTP conversation, only recorded when EnableDebugTranscript
+
+	MXDiagnostics *MXDiagnostics `json:"mx_diagnostics,omitempty"` // DNS detail of the MX host, only recorded when EnableMXDiagnostics
+
+	Timings *SMTPTimings `json:"timings,omitempty"` // durations of the stages of the check, only recorded when EnableTimings
 }
 
 // CheckSMTP performs an email verification on the passed domain via SMTP
 //   - the domain is the passed email domain
 //   - username is used to check the deliverability of specific email address,
 //
-// if server is catch-all server, username will not be checked
+// if server is catch-all server, username can't be deliverable, only a rejection of
+// it is reported in CatchAllWithRejection. With an empty username only the host and
+// the catch-all probe are checked, see CheckDomain.
 func (v *Verifier) CheckSMTP(domain, username string) (*SMTP, error) {
+	v = v.snapshot()
+	return v.checkSMTP(context.Background(), domain, username)
+}
+
+// SMTPOptions overrides the settings of the Verifier for a single SMTP check,
+// e.g. the MAIL FROM identity of a tenant. Zero fields keep the settings of the Verifier.
+type SMTPOptions struct {
+	FromEmail        string        // email used in the MAIL FROM command, overrides FromEmail and UseNullSender
+	HelloName        string        // name used in the EHLO/HELO command, overrides HelloName and HelloNameFunc
+	Proxy            string        // SOCKS5 or HTTP proxy URI to connect through, overrides Proxy and ProxyPool
+	ConnectTimeout   time.Duration // timeout for establishing connections, overrides ConnectTimeout
+	OperationTimeout time.Duration // timeout for SMTP operations, overrides OperationTimeout
+
+	// ExpectedSize is the size in bytes of the message to be sent, declared with the
+	// SIZE parameter of MAIL FROM when the server advertises the SIZE extension, so
+	// that a message the server won't take is reported as ErrMessageTooLarge.
+	// It is ignored when zero or when the server doesn't advertise SIZE.
+	ExpectedSize int
+}
+
+// CheckSMTPWithOptions performs the email verification of CheckSMTP with the settings
+// of opts overriding the ones of the Verifier for this call only. Unlike calling
+// FromEmail or HelloName before each check, it is safe for concurrent use, the
+// setters of the Verifier only provide the defaults.
+func (v *Verifier) CheckSMTPWithOptions(domain, username string, opts SMTPOptions) (*SMTP, error) {
+	v = v.snapshot()
+	return v.checkSMTPWithOptions(context.Background(), domain, username, opts)
+}
+
+// checkSMTP is CheckSMTP bound to ctx, the SMTP connection is closed
+// as soon as ctx is done
+func (v *Verifier) checkSMTP(ctx context.Context, domain, username string) (*SMTP, error) {
+	return v.checkSMTPWithOptions(ctx, domain, username, v.smtpOverrides)
+}
+
+// checkSMTPWithOptions is CheckSMTPWithOptions bound to ctx
+func (v *Verifier) checkSMTPWithOptions(ctx context.Context, domain, username string, overrides SMTPOptions) (*SMTP, error) {
+	ret, _, err := v.checkMailboxBackend(ctx, domain, username, nil, overrides)
+	return ret, err
+}
+
+// checkMailboxBackend is checkSMTPWithOptions returning the MailboxBackend which
+// checked the mailbox, empty when SMTP is disabled or ctx is done. The hosts of
+// mxRecords, e.g. found by the mx check, are dialed without looking them up again.
+func (v *Verifier) checkMailboxBackend(ctx context.Context, domain, username string, mxRecords []*net.MX, overrides SMTPOptions) (*SMTP, string, error) {
+	if !v.smtpCheckEnabled {
+		return nil, "", nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	if v.isTrustedDomain(domain) {
+		return v.trustedSMTP(domain), MailboxBackendTrusted, nil
+	}
+
+	// Check by a registered mailbox checker or by api when enabled and host recognized,
+	// without connecting to the SMTP server. They check the mailbox, so they are skipped
+	// in MX-only mode.
+	if !v.mxOnlyMode {
+		if ret, backend, err := v.checkMailboxWithoutSMTP(ctx, domain, username); ret != nil || err != nil {
+			if ret != nil && ret.CatchAllStatus == "" {
+				// API verifiers don't probe for a catch-all address
+				ret.CatchAllStatus = CatchAllUnknown
+			}
+			return ret, backend, err
+		}
+		if relay := v.relayOf(domain); relay != nil {
+			ret, err := v.checkSMTPByRelay(ctx, relay, domain, username, overrides)
+			return ret, MailboxBackendRelay, err
+		}
+	}
+	ret, err := v.checkSMTPOfMX(ctx, domain, username, mxRecords, overrides)
+	return ret, MailboxBackendSMTP, err
+}
+
+// checkSMTPOfMX performs the SMTP check of the mailbox on the MX hosts of domain,
+// the hosts of mxRecords when it isn't empty
+func (v *Verifier) checkSMTPOfMX(ctx context.Context, domain, username string, mxRecords []*net.MX, overrides SMTPOptions) (*SMTP, error) {
+	opts := v.smtpDialOptions(ctx, domain).with(overrides)
+	opts.mxRecords = mxRecords
+
+	// Dial any SMTP server that will accept a connection
+	dialStart := time.Now()
+	client, mx, err := v.newSMTPClient(domain, opts)
+	if mx != nil {
+		v.observer.OnSMTPDial(mx.Host, time.Since(dialStart), err)
+	} else {
+		v.observer.OnSMTPDial(domain, time.Since(dialStart), err)
+	}
+	if err != nil {
+		var diag *MXDiagnostics
+		if v.mxDiagnosticsEnabled {
+			diag = v.diagnoseMX(ctx, preferredMXHost(domain))
+		}
+		return &SMTP{CatchAllStatus: CatchAllUnknown, Transcript: opts.transcript.linesOf(""), MXDiagnostics: diag}, ParseSMTPError(withStage(SMTPStageConnect, err))
+	}
+	return v.checkSMTPSession(ctx, client, mx.Host, domain, username, opts, v.redial(mx.Host+smtpPort, opts))
+}
+
+// CheckSMTPWithMX performs the email verification of CheckSMTP against the given MX host,
+// e.g. a backup MX or a host known from a prior lookup, without looking up the MX records
+// of the domain. The domain is still used for the RCPT address. mxHost is a host name or
+// an IP address without port, the configured timeouts and proxies are used to connect.
+func (v *Verifier) CheckSMTPWithMX(mxHost, domain, username string) (*SMTP, error) {
+	v = v.snapshot()
+	return v.checkSMTPWithMX(context.Background(), mxHost, domain, username)
+}
+
+// checkSMTPWithMX is CheckSMTPWithMX bound to ctx
+func (v *Verifier) checkSMTPWithMX(ctx context.Context, mxHost, domain, username string) (*SMTP, error) {
 	if !v.smtpCheckEnabled {
 		return nil, nil
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	var ret SMTP
+	opts := v.smtpDialOptions(ctx, domain)
+	dialStart := time.Now()
+	client := opts.pool.getHost(mxHost, opts)
 	var err error
-	email := fmt.Sprintf("%s@%s", username, domain)
+	if client == nil {
+		client, err = v.dialMX(mxHost, opts)
+	}
+	v.observer.OnSMTPDial(mxHost, time.Since(dialStart), err)
+	if err != nil {
+		return &SMTP{CatchAllStatus: CatchAllUnknown, Transcript: opts.transcript.linesOf(""), MXDiagnostics: v.diagnoseMX(ctx, mxHost)}, ParseSMTPError(withStage(SMTPStageConnect, err))
+	}
+	return v.checkSMTPSession(ctx, client, mxHost, domain, username, opts, v.redial(mxHost+smtpPort, opts))
+}
 
-	// Dial any SMTP server that will accept a connection
-	client, mx, err := newSMTPClientWithStrategy(domain, v.proxyURI, v.connectTimeout, v.operationTimeout, v.mxStrategy)
+// CheckSMTPAtAddr performs the email verification of CheckSMTP against the SMTP
+// server at addr, without any DNS lookup of the domain or its MX hosts, e.g. for
+// hosts behind split-horizon DNS. addr is "host:port" or "ip:port" (IPv6 addresses
+// in brackets), the domain is still used for the RCPT address. The configured
+// timeouts and proxies are used to connect, the connection isn't pooled.
+func (v *Verifier) CheckSMTPAtAddr(addr, domain, username string) (*SMTP, error) {
+	v = v.snapshot()
+	return v.checkSMTPAtAddr(context.Background(), addr, domain, username)
+}
+
+// checkSMTPAtAddr is CheckSMTPAtAddr bound to ctx
+func (v *Verifier) checkSMTPAtAddr(ctx context.Context, addr, domain, username string) (*SMTP, error) {
+	if !v.smtpCheckEnabled {
+		return nil, nil
+	}
+	if err := validateAddr(addr); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opts := v.smtpDialOptions(ctx, domain)
+	// the pool is keyed by host, whatever the port
+	opts.pool = nil
+	host, _, _ := net.SplitHostPort(addr)
+	dialStart := time.Now()
+	client, err := v.dialAddr(addr, opts)
+	v.observer.OnSMTPDial(host, time.Since(dialStart), err)
 	if err != nil {
-		return &ret, ParseSMTPError(err)
+		return &SMTP{CatchAllStatus: CatchAllUnknown, Transcript: opts.transcript.linesOf(""), MXDiagnostics: v.diagnoseMX(ctx, host)}, ParseSMTPError(withStage(SMTPStageConnect, err))
 	}
+	return v.checkSMTPSession(ctx, client, host, domain, username, opts, v.redial(addr, opts))
+}
 
-	// Defer quit the SMTP connection
-	defer client.Close()
+// CheckSMTPOverConn performs the email verification of CheckSMTP over conn, an already
+// established connection to an SMTP server, e.g. tunneled through another connection
+// or an in-memory pipe in tests. Nothing is dialed nor looked up: the greeting of the
+// server is read from conn, then the EHLO, MAIL and RCPT commands are sent over it.
+// The server is named after the host of conn's remote address, or the domain when the
+// address has no host. The verifier owns conn, which is closed once the check is done,
+// and a server closing the connection after the catch-all probe isn't reconnected to.
+func (v *Verifier) CheckSMTPOverConn(conn net.Conn, domain, username string) (*SMTP, error) {
+	v = v.snapshot()
+	return v.checkSMTPOverConn(context.Background(), conn, domain, username)
+}
 
-	// Check by api when enabled and host recognized.
-	for _, apiVerifier := range v.apiVerifiers {
-		if apiVerifier.isSupported(strings.ToLower(mx.Host)) {
-			return apiVerifier.check(domain, username)
+// checkSMTPOverConn is CheckSMTPOverConn bound to ctx
+func (v *Verifier) checkSMTPOverConn(ctx context.Context, conn net.Conn, domain, username string) (*SMTP, error) {
+	if !v.smtpCheckEnabled {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	opts := v.smtpDialOptions(ctx, domain)
+	// the connection can't be redialed, so it isn't pooled
+	opts.pool = nil
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil || host == "" {
+		host = domain
+	}
+	var client *smtp.Client
+	if err = conn.SetDeadline(time.Now().Add(opts.operationTimeout)); err == nil {
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+		client, err = newSMTPClientOverConn(conn, host, opts)
+		stop()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return &SMTP{CatchAllStatus: CatchAllUnknown, Transcript: opts.transcript.linesOf("")}, ParseSMTPError(withStage(SMTPStageConnect, err))
+	}
+	return v.checkSMTPSession(ctx, client, host, domain, username, opts, nil)
+}
+
+// validateAddr checks that addr is a "host:port" address with a port from 1 to 65535
+func validateAddr(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return fmt.Errorf("missing host in address %q", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port in address %q", addr)
+	}
+	return nil
+}
+
+// smtpDialOptions returns the dial options of an SMTP check of domain bound to ctx,
+// with a transcript when the debug transcript is enabled. The connection pool isn't
+// used along with the transcript, which records the conversation of a single check.
+func (v *Verifier) smtpDialOptions(ctx context.Context, domain string) dialOptions {
+	opts := v.dialOptions()
+	opts.ctx = ctx
+	opts.helloName = v.helloNameFor(domain)
+	opts.fromEmail = v.fromEmail
+	if v.nullSender {
+		opts.fromEmail = ""
+	}
+	if v.timingsEnabled {
+		opts.timings = newDialTimings()
+	}
+	if v.transcriptEnabled {
+		opts.transcript = &transcript{}
+	} else {
+		opts.pool = v.pool
+	}
+	return opts
+}
+
+// redial returns the reconnection to addr ("host:port") of a server closing the
+// connection after the catch-all probe, the client of the reconnection isn't pooled
+func (v *Verifier) redial(addr string, opts dialOptions) func() (*smtp.Client, error) {
+	return func() (*smtp.Client, error) {
+		opts := opts
+		opts.pool = nil
+		return v.dialAddr(addr, opts)
+	}
+}
+
+// checkSMTPSession performs the SMTP check on client connected to host and ends the
+// session, the connection is closed as soon as ctx is done. reconnect may be nil,
+// see checkSMTPClient.
+func (v *Verifier) checkSMTPSession(ctx context.Context, client *smtp.Client, host, domain, username string, opts dialOptions, reconnect func() (*smtp.Client, error)) (*SMTP, error) {
+	var ret *SMTP
+	var err error
+
+	// Defer quit the SMTP connection, or give it back to the pool for the next checks
+	defer func() { opts.pool.release(client, host, opts, err) }()
+	stop := context.AfterFunc(ctx, func() { _ = client.Close() })
+	defer stop()
+
+	ret, err = v.checkSMTPClient(ctx, client, host, domain, username, opts, reconnect)
+	if ret != nil {
+		v.applyProviderBehavior(ret, host)
+		if v.catchAllAsDeliverable && ret.HostExists && ret.CatchAllStatus == CatchAllYes {
+			ret.Deliverable = true
 		}
+		ret.Transcript = opts.transcript.linesOf(host)
+		ret.MXDiagnostics = v.diagnoseMX(ctx, host)
+	}
+	if isThrottled(err) {
+		v.logger.Warn("MX host throttles us, tightening its rate limit", "host", host, "error", err)
+		v.limiter.tighten(host)
+	} else if err == nil {
+		v.limiter.relax(host)
 	}
+	var lookupErr *LookupError
+	if errors.As(err, &lookupErr) && lookupErr.Stage != "" {
+		v.logger.Debug("connection reset by MX host", "host", host, "stage", lookupErr.Stage)
+	}
+	if errors.As(err, &lookupErr) && lookupErr.Retryable() {
+		v.logger.Info("temporary SMTP failure (e.g. greylisting), retry later", "host", host, "error", err)
+	}
+	return ret, err
+}
+
+// checkSMTPClient performs the SMTP conversation of CheckSMTP on an established client,
+// the conversation is recorded in tr when it isn't nil. The same client is used for
+// the catch-all probe and the mailbox check, unless the server closes the connection
+// after the probe and reconnect isn't nil. The client is greeted with the hello name
+// and from email of opts.
+func (v *Verifier) checkSMTPClient(ctx context.Context, client *smtp.Client, host, domain, username string, opts dialOptions, reconnect func() (*smtp.Client, error)) (*SMTP, error) {
+	ret := SMTP{CatchAllStatus: CatchAllUnknown, Timings: opts.timings.of(client)}
+	tr := opts.transcript
+	// a client of the pool sent its last RCPT at the end of its previous check
+	pacer := &rcptPacer{ctx: ctx, delay: v.rcptDelay, last: opts.pool.idleSince(client)}
+	var err error
+	email := fmt.Sprintf("%s@%s", username, domain)
+	provider := mailProviderOf(host)
+	behavior := v.providerBehavior(provider)
 
-	// Sets the HELO/EHLO hostname
-	if err = client.Hello(v.helloName); err != nil {
-		return &ret, ParseSMTPError(err)
+	// Only confirms the host accepts the connection and EHLO, mailbox-level checks are skipped
+	if v.mxOnlyMode {
+		if err = v.timedHello(client, opts, ret.Timings); err != nil {
+			return &ret, ParseSMTPError(withStage(SMTPStageEHLO, err))
+		}
+		ret.HostExists = true
+		ret.Extensions = extensions(client)
+		ret.MailboxCheckSkipped = true
+		return &ret, nil
 	}
 
-	// Sets the from email
-	if err = client.Mail(v.fromEmail); err != nil {
-		return &ret, ParseSMTPError(err)
+	if err = v.startMailTransaction(client, opts, ret.Timings); err != nil {
+		e := ParseSMTPError(err)
+		if e != nil && e.Message == ErrMessageTooLarge {
+			// the host exists but won't take a message of the expected size for anyone
+			ret.HostExists = true
+			ret.Extensions = extensions(client)
+			ret.Error = e
+			return &ret, nil
+		}
+		return &ret, e
 	}
 
 	// Host exists if we've successfully formed a connection
 	ret.HostExists = true
+	ret.Extensions = extensions(client)
 
-	// Default sets catch-all to true
+	// Default sets catch-all to true, the status stays unknown until probed
 	ret.CatchAll = true
+	probe := v.catchAllCheckEnabled
+	if behavior.NoCatchAll {
+		ret.CatchAll = false
+		ret.CatchAllStatus = CatchAllNo
+		tr.note(provider + " hosts no catch-all domains, catch-all probe skipped")
+		probe = false
+	}
 
-	if v.catchAllCheckEnabled {
-		// Checks the deliver ability of a randomly generated address in
-		// order to verify the existence of a catch-all and etc.
-		randomEmail := GenerateRandomEmail(domain)
-		if err = client.Rcpt(randomEmail); err != nil {
-			if e := ParseSMTPError(err); e != nil {
-				switch e.Message {
-				case ErrFullInbox:
-					ret.FullInbox = true
-				case ErrNotAllowed:
-					ret.Disabled = true
-					ret.CatchAll = false
-				case ErrServerUnavailable:
-					ret.CatchAll = false
-				// If The client typically receives a `550 5.1.1` code as a reply to RCPT TO command,
-				// In most cases, this is because the recipient address does not exist.
-				case ErrMailboxNotFound:
-					ret.CatchAll = false
-				default:
-					ret.CatchAll = false
-				}
-			}
-		}
-
-		// If the email server is a catch-all email server,
-		// no need to calibrate deliverable on a specific user
+	// Without a catch-all timeout the probe comes first,
+	// a catch-all server needs no check of the specific user
+	if probe && v.catchAllTimeout <= 0 {
+		probeErr := v.probeCatchAllOfHost(client, host, domain, tr, pacer, &ret)
 		if ret.CatchAll {
+			if username != "" {
+				v.checkCatchAllTarget(client, email, pacer, &ret)
+			}
 			return &ret, nil
 		}
+		if username != "" && reconnect != nil && isConnectionClosed(probeErr) {
+			v.logger.Debug("connection closed after the catch-all probe, reconnecting", "domain", domain)
+			if client, err = reconnect(); err != nil {
+				return nil, ParseSMTPError(withStage(SMTPStageConnect, err))
+			}
+			ret.Timings.addDial(opts.timings.of(client))
+			defer quitSMTPClient(client)
+			stop := context.AfterFunc(ctx, func() { _ = client.Close() })
+			defer stop()
+			if err = v.startMailTransaction(client, opts, ret.Timings); err != nil {
+				return nil, ParseSMTPError(err)
+			}
+		}
 	}
 
 	// If no username provided,
 	// no need to calibrate deliverable on a specific user
-	if username == "" {
-		return &ret, nil
+	if username != "" {
+		if v.vrfyEnabled && vrfyMailbox(client, email, &ret) {
+			ret.MailboxCheckMethod = MailboxCheckVRFY
+		} else {
+			if err = pacer.wait(); err != nil {
+				return nil, ParseSMTPError(withStage(SMTPStageRCPT, err))
+			}
+			if err = checkMailbox(client, email, behavior.MailboxNotFoundPhrases, &ret); err != nil {
+				return nil, err
+			}
+			if v.vrfyEnabled {
+				ret.MailboxCheckMethod = MailboxCheckRCPT
+			}
+		}
+	}
+
+	// With a catch-all timeout the probe comes last, as a timed out probe
+	// leaves the connection unusable and mustn't lose the verdict of the user
+	if probe && v.catchAllTimeout > 0 {
+		_ = v.probeCatchAllOfHost(client, host, domain, tr, pacer, &ret)
+		if ret.CatchAll {
+			// consistent with probing first, a catch-all server says nothing about the user
+			ret.Deliverable = false
+			ret.CatchAllWithRejection = username != "" && ret.Error != nil
+		}
+	}
+
+	return &ret, nil
+}
+
+// checkCatchAllTarget sends the RCPT of email to a host found catch-all, which
+// still rejects some addresses (e.g. reserved local parts). A rejection is recorded
+// in ret along with CatchAllWithRejection, an acceptance says nothing about the user
+// so Deliverable stays false. Server problems leave ret unchanged.
+func (v *Verifier) checkCatchAllTarget(client *smtp.Client, email string, pacer *rcptPacer, ret *SMTP) {
+	if err := pacer.wait(); err != nil {
+		return
+	}
+	target := SMTP{Timings: ret.Timings}
+	if err := checkMailbox(client, email, nil, &target); err != nil {
+		v.logger.Debug("RCPT of the target failed on a catch-all host", "email", email, "error", err)
+		return
+	}
+	if target.Deliverable || target.Error == nil {
+		return
+	}
+	ret.CatchAllWithRejection = true
+	ret.Error = target.Error
+	ret.FullInbox = target.FullInbox
+	ret.FullInboxPermanent = target.FullInboxPermanent
+	ret.Disabled = target.Disabled
+}
+
+// startMailTransaction sends the HELO/EHLO hostname and the from email of opts,
+// the error is annotated with the stage it happened at
+func (v *Verifier) startMailTransaction(client *smtp.Client, opts dialOptions, timings *SMTPTimings) error {
+	if err := v.timedHello(client, opts, timings); err != nil {
+		return withStage(SMTPStageEHLO, err)
+	}
+	return v.mail(client, opts, timings)
+}
+
+// mail sends the from email of opts to a client which greeted the server,
+// the error is annotated with the MAIL stage
+func (v *Verifier) mail(client *smtp.Client, opts dialOptions, timings *SMTPTimings) error {
+	if timings != nil {
+		defer func(start time.Time) { timings.MailFrom += time.Since(start) }(time.Now())
+	}
+
+	// Sets the from email, an empty one is the null sender "<>"
+	v.logger.Debug("starting the mail transaction", "sender", "<"+opts.fromEmail+">")
+	if ok, _ := client.Extension("SIZE"); ok && opts.expectedSize > 0 {
+		return withStage(SMTPStageMAIL, mailWithSize(client, opts.fromEmail, opts.expectedSize))
+	}
+	return withStage(SMTPStageMAIL, client.Mail(opts.fromEmail))
+}
+
+// mailWithSize issues the MAIL command of client.Mail with the SIZE parameter
+// of RFC 1870, which net/smtp doesn't support
+func mailWithSize(client *smtp.Client, from string, size int) error {
+	if strings.ContainsAny(from, "\r\n") {
+		return errors.New("smtp: A line must not contain CR or LF")
+	}
+	cmd := "MAIL FROM:<%s>"
+	if ok, _ := client.Extension("8BITMIME"); ok {
+		cmd += " BODY=8BITMIME"
+	}
+	if ok, _ := client.Extension("SMTPUTF8"); ok {
+		cmd += " SMTPUTF8"
+	}
+	cmd += " SIZE=" + strconv.Itoa(size)
+
+	id, err := client.Text.Cmd(cmd, from)
+	if err != nil {
+		return err
+	}
+	client.Text.StartResponse(id)
+	defer client.Text.EndResponse(id)
+	_, _, err = client.Text.ReadResponse(250)
+	return err
+}
+
+// timedHello is hello recording its duration in timings when it isn't nil,
+// the hello skipped on a pooled client isn't recorded
+func (v *Verifier) timedHello(client *smtp.Client, opts dialOptions, timings *SMTPTimings) error {
+	if timings == nil || v.pool.isReused(client) {
+		return v.hello(client, opts)
+	}
+	start := time.Now()
+	err := v.hello(client, opts)
+	timings.EHLO += time.Since(start)
+	return err
+}
+
+// hello sends the HELO/EHLO hostname of opts, EHLO is tried first and the client
+// falls back to HELO when the server rejects it. Clients reused from the connection
+// pool already greeted the server with the same name.
+func (v *Verifier) hello(client *smtp.Client, opts dialOptions) error {
+	if v.pool.isReused(client) {
+		return nil
+	}
+	return client.Hello(opts.helloName)
+}
+
+// KnownSMTPExtensions are the EHLO extensions reported in SMTP.Extensions, net/smtp
+// doesn't expose the others. It may be extended before the verifier is used, it
+// must not be modified concurrently.
+var KnownSMTPExtensions = []string{
+	"8BITMIME",
+	"AUTH",
+	"BINARYMIME",
+	"CHUNKING",
+	"DSN",
+	"ENHANCEDSTATUSCODES",
+	"ETRN",
+	"PIPELINING",
+	"REQUIRETLS",
+	"SIZE",
+	"SMTPUTF8",
+	"STARTTLS",
+	"VRFY",
+}
+
+// extensions returns the KnownSMTPExtensions advertised by the server of client,
+// nil when it advertises none. The client must have greeted the server.
+func extensions(client *smtp.Client) map[string]string {
+	var ext map[string]string
+	for _, name := range KnownSMTPExtensions {
+		if ok, params := client.Extension(name); ok {
+			if ext == nil {
+				ext = make(map[string]string)
+			}
+			ext[name] = params
+		}
+	}
+	return ext
+}
+
+// catchAllProbeOutcome is the outcome of the RCPT of a single catch-all probe
+type catchAllProbeOutcome int
+
+const (
+	probeAccepted catchAllProbeOutcome = iota // the random address was accepted
+	probeRejected                             // the random address was rejected
+	probeAborted                              // the probe timed out or the server closed the connection
+)
+
+// probeCatchAll checks the deliver ability of randomly generated addresses in
+// order to verify the existence of a catch-all and etc. CatchAllProbeCount addresses
+// are probed, the domain is a catch-all when all of them are accepted and isn't when
+// all of them are rejected, mixed outcomes leave the catch-all status unknown.
+// The error of the last probe is returned. When a probe exceeds the catch-all timeout
+// the client is closed and the catch-all status is left unknown. The probes are
+// spaced by pacer.
+func (v *Verifier) probeCatchAll(client *smtp.Client, domain string, tr *transcript, pacer *rcptPacer, ret *SMTP) error {
+	probes := max(v.catchAllProbeCount, 1)
+	if v.catchAllProbeStyle != "" {
+		tr.note(fmt.Sprintf("catch-all probe style: %s", v.catchAllProbeStyle))
+		v.logger.Debug("probing catch-all", "domain", domain, "style", string(v.catchAllProbeStyle))
+	}
+	var accepted, rejected int
+	var err error
+	for i := 1; i <= probes; i++ {
+		var outcome catchAllProbeOutcome
+		outcome, err = v.probeRandomAddress(client, domain, tr, pacer, ret)
+		if probes > 1 {
+			tr.note(fmt.Sprintf("catch-all probe %d/%d: %s", i, probes, outcome))
+		}
+		switch outcome {
+		case probeAccepted:
+			accepted++
+		case probeRejected:
+			rejected++
+		case probeAborted:
+			ret.CatchAll = false
+			ret.CatchAllStatus = CatchAllUnknown
+			return err
+		}
+	}
+
+	switch {
+	case rejected == 0:
+		ret.CatchAll = true
+		ret.CatchAllStatus = CatchAllYes
+	case accepted == 0:
+		ret.CatchAll = false
+		ret.CatchAllStatus = CatchAllNo
+	default:
+		// the server filters some local parts, it tells nothing about a catch-all
+		ret.CatchAll = false
+		ret.CatchAllStatus = CatchAllUnknown
+	}
+	return err
+}
+
+// probeRandomAddress issues the RCPT command for a randomly generated address of domain
+func (v *Verifier) probeRandomAddress(client *smtp.Client, domain string, tr *transcript, pacer *rcptPacer, ret *SMTP) (catchAllProbeOutcome, error) {
+	if err := pacer.wait(); err != nil {
+		return probeAborted, err
+	}
+	randomEmail := fmt.Sprintf("%s@%s", v.catchAllProbeStyle.localPart(), domain)
+	if v.transcriptRedactProbe {
+		tr.redact(randomEmail[:strings.LastIndex(randomEmail, "@")])
+	}
+	start := time.Now()
+	timedOut, err := rcptWithTimeout(client, randomEmail, v.catchAllTimeout)
+	if ret.Timings != nil {
+		ret.Timings.CatchAllRCPT += time.Since(start)
+	}
+	if timedOut {
+		return probeAborted, nil
+	}
+
+	if isConnectionClosed(err) {
+		// the server closed the connection rather than answering the probe
+		return probeAborted, err
+	}
+
+	if err != nil {
+		if e := ParseSMTPError(err); e != nil {
+			switch e.Message {
+			case ErrFullInbox:
+				// a full inbox for a random address still means a catch-all server
+				ret.FullInbox = true
+				ret.FullInboxPermanent = !e.Temporary
+				return probeAccepted, err
+			case ErrNotAllowed, ErrMailboxDisabled:
+				ret.Disabled = true
+			}
+			// In most cases the probe is rejected with `550 5.1.1`,
+			// because the recipient address does not exist.
+			return probeRejected, err
+		}
+	}
+	return probeAccepted, err
+}
+
+// String returns the outcome as recorded in the transcript
+func (o catchAllProbeOutcome) String() string {
+	switch o {
+	case probeAccepted:
+		return "accepted"
+	case probeRejected:
+		return "rejected"
+	case probeAborted:
+		return "aborted"
+	default:
+		return "unknown"
 	}
+}
 
-	if err = client.Rcpt(email); err == nil {
+// checkMailbox checks the deliverability of email, errors indicating server
+// problems are returned to the caller. A permanent rejection containing one of
+// notFound, the phrases of the provider, means the mailbox doesn't exist.
+func checkMailbox(client *smtp.Client, email string, notFound []string, ret *SMTP) error {
+	start := time.Now()
+	err := client.Rcpt(email)
+	if ret.Timings != nil {
+		ret.Timings.TargetRCPT += time.Since(start)
+	}
+	if err == nil {
 		ret.Deliverable = true
-		return &ret, nil
+		return nil
 	}
 
-	if e := ParseSMTPError(err); e != nil {
+	if e := ParseSMTPError(withStage(SMTPStageRCPT, err)); e != nil {
+		if !e.Temporary && insContains(e.Details, notFound...) {
+			e.Message = ErrMailboxNotFound
+		}
+		ret.Error = e
 		switch e.Message {
 		case ErrFullInbox:
-			ret.FullInbox = true // mailbox exists but is currently full
-		case ErrNotAllowed:
+			ret.FullInbox = true // mailbox exists but is full, for now or for good
+			ret.FullInboxPermanent = !e.Temporary
+		case ErrNotAllowed, ErrMailboxDisabled:
 			ret.Disabled = true // account disabled / not accepting mail
-		case ErrExceededMessagingLimits, ErrTimeout, ErrBlocked, ErrMailboxBusy, ErrServerUnavailable, ErrTryAgainLater, ErrTLSVersion:
+		case ErrExceededMessagingLimits, ErrTimeout, ErrBlocked, ErrMailboxBusy, ErrServerUnavailable, ErrTryAgainLater, ErrTLSVersion, ErrConnectionDropped:
 			// these errors indicate server problems that should be surfaced to the caller
-			return nil, e
+			return e
 		case ErrNoRelay: // server doesn't recognise email domain, so complains about relay access (account does not exist)
 			// ret.Deliverable stays as false
 		case ErrMailboxNotFound:
@@ -133,15 +780,183 @@ func (v *Verifier) CheckSMTP(domain, username string) (*SMTP, error) {
 			// for all other errors, retain the current behavior (ignore error)
 		}
 	}
+	return nil
+}
 
-	return &ret, nil
+// vrfyMailbox verifies email with VRFY when the server advertises it and reports
+// whether the reply was authoritative: 250/251 mean the mailbox exists and
+// 550/551/553 that it doesn't. Other replies, e.g. 252 (cannot verify, will
+// accept) or 502 (command disabled), leave the verdict to the RCPT probe.
+func vrfyMailbox(client *smtp.Client, email string, ret *SMTP) bool {
+	if ok, _ := client.Extension("VRFY"); !ok {
+		return false
+	}
+	err := client.Verify(email)
+	if err == nil {
+		ret.Deliverable = true
+		return true
+	}
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) {
+		return false
+	}
+	switch protoErr.Code {
+	case 251:
+		ret.Deliverable = true
+		return true
+	case 550, 551, 553:
+		ret.Error = ParseSMTPError(err)
+		return true
+	default:
+		return false
+	}
+}
+
+// rcptPacer spaces the RCPT commands sent on a connection by delay
+type rcptPacer struct {
+	ctx   context.Context
+	delay time.Duration
+	last  time.Time // when the last RCPT was sent, zero before the first one
+}
+
+// wait pauses until delay elapsed since the last RCPT, it returns the error of
+// ctx when ctx is done first
+func (p *rcptPacer) wait() error {
+	if p.delay <= 0 {
+		return nil
+	}
+	if pause := p.delay - time.Since(p.last); !p.last.IsZero() && pause > 0 {
+		timer := time.NewTimer(pause)
+		defer timer.Stop()
+		select {
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		case <-timer.C:
+		}
+	}
+	p.last = time.Now()
+	return nil
+}
+
+// rcptWithTimeout issues the RCPT command for addr, the client is closed when
+// no reply is received within timeout. A timeout <= 0 means no timeout.
+func rcptWithTimeout(client *smtp.Client, addr string, timeout time.Duration) (timedOut bool, err error) {
+	if timeout <= 0 {
+		return false, client.Rcpt(addr)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- client.Rcpt(addr) }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err = <-done:
+		return false, err
+	case <-timer.C:
+		_ = client.Close()
+		<-done
+		return true, nil
+	}
+}
+
+// quitSMTPClient ends the SMTP session with QUIT, the connection is closed
+// anyway when the server doesn't reply
+func quitSMTPClient(client *smtp.Client) {
+	if err := client.Quit(); err != nil {
+		_ = client.Close()
+	}
+}
+
+// isConnectionClosed reports whether err means the server closed the connection,
+// by replying 421 or dropping it
+func isConnectionClosed(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code == 421
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// dialMX connects to the SMTP server of host through the proxy pool,
+// the next proxy is tried when the connection fails because of the proxy
+func (v *Verifier) dialMX(host string, opts dialOptions) (*smtp.Client, error) {
+	return v.dialAddr(host+smtpPort, opts)
+}
+
+// dialAddr connects to the SMTP server at addr ("host:port") like dialMX
+func (v *Verifier) dialAddr(addr string, opts dialOptions) (*smtp.Client, error) {
+	var client *smtp.Client
+	var err error
+	for _, proxyURI := range v.proxyCandidates(opts) {
+		opts.proxyURI = proxyURI
+		client, err = dialHost(addr, opts)
+		if err == nil || proxyURI == "" || !isProxyError(err) {
+			return client, err
+		}
+		v.logger.Warn("proxy failed, switching to the next proxy", "proxy", redactProxyURI(proxyURI), "error", err)
+	}
+	return client, err
+}
+
+// proxyCandidates returns the proxies to try in order, the proxy of opts is the
+// only candidate when it is set, e.g. by SMTPOptions or a previous dial of the check
+func (v *Verifier) proxyCandidates(opts dialOptions) []string {
+	if opts.proxyURI != "" {
+		return []string{opts.proxyURI}
+	}
+	return v.proxies.candidates()
+}
+
+// newSMTPClient generates a new available SMTP client through the proxy pool,
+// the next proxy is tried when the connection fails because of the proxy
+func (v *Verifier) newSMTPClient(domain string, opts dialOptions) (*smtp.Client, *net.MX, error) {
+	var client *smtp.Client
+	var mx *net.MX
+	var err error
+	for _, proxyURI := range v.proxyCandidates(opts) {
+		opts.proxyURI = proxyURI
+		client, mx, err = newSMTPClientWithStrategy(domain, opts, v.mxStrategy)
+		if err == nil || proxyURI == "" || !isProxyError(err) {
+			return client, mx, err
+		}
+		v.logger.Warn("proxy failed, switching to the next proxy", "proxy", redactProxyURI(proxyURI), "error", err)
+	}
+	return client, mx, err
+}
+
+// lookupSMTPMX looks up the MX records of domain for the SMTP check, following
+// at most opts.maxCNAMEDepth CNAMEs when it is set
+func lookupSMTPMX(domain string, opts dialOptions) ([]*net.MX, error) {
+	if opts.maxCNAMEDepth > 0 {
+		records, fallback, err := lookupMXWithCNAMEDepth(opts.context(), domain, opts.maxCNAMEDepth, opts.logger)
+		if !fallback {
+			return netMX(records), err
+		}
+	}
+	return lookupMX(domain)
 }
 
 // newSMTPClientWithStrategy generates a new available SMTP client according to
-// the provided MX strategy.
-func newSMTPClientWithStrategy(domain, proxyURI string, connectTimeout, operationTimeout time.Duration, strategy MXStrategy) (*smtp.Client, *net.MX, error) {
+// the provided MX strategy. The MX records of domain are looked up unless opts
+// carries them.
+func newSMTPClientWithStrategy(domain string, opts dialOptions, strategy MXStrategy) (*smtp.Client, *net.MX, error) {
 	domain = domainToASCII(domain)
-	mxRecords, err := lookupMX(domain)
+	mxRecords := opts.mxRecords
+	var err error
+	if len(mxRecords) == 0 {
+		mxRecords, err = lookupSMTPMX(domain, opts)
+	}
+	if hasNoMX(mxRecords, err) {
+		// fall back to the A/AAAA record of the domain, see RFC 5321 section 5.1
+		if records, ok := implicitMX(opts.context(), domain); ok {
+			if opts.logger != nil {
+				opts.logger.Info("no MX records, falling back to the A/AAAA record", "domain", domain)
+			}
+			mxRecords, err = records, nil
+		}
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -149,81 +964,106 @@ func newSMTPClientWithStrategy(domain, proxyURI string, connectTimeout, operatio
 	if len(mxRecords) == 0 {
 		return nil, nil, errors.New("No MX records found")
 	}
+	if isNullMX(mxRecords) {
+		return nil, nil, errNullMX
+	}
+	if opts.mxLoadSpreading {
+		mxRecords = shuffleMXRecords(mxRecords)
+	}
+	mxRecords = limitMXRecords(mxRecords, opts.maxMXAttempts)
+
+	if client, mx := opts.pool.get(mxRecords, opts); client != nil {
+		return client, mx, nil
+	}
 
 	switch strategy {
 	case MXStrategyPriority:
-		return newSMTPClientPriority(mxRecords, proxyURI, connectTimeout, operationTimeout)
+		return newSMTPClientPriority(mxRecords, opts)
 	case MXStrategyFirstConnected:
 		fallthrough
 	default:
-		return newSMTPClientFirstConnected(mxRecords, proxyURI, connectTimeout, operationTimeout)
+		return newSMTPClientFirstConnected(mxRecords, opts)
 	}
 }
 
 // newSMTPClientFirstConnected implements the behaviour: attempt to
-// connect to all SMTP hosts concurrently and return the first successful
-// connection, ignoring MX priority.
-func newSMTPClientFirstConnected(mxRecords []*net.MX, proxyURI string, connectTimeout, operationTimeout time.Duration) (*smtp.Client, *net.MX, error) {
-	// Create a channel for receiving response from
-	ch := make(chan interface{}, 1)
-	selectedMXCh := make(chan *net.MX, 1)
-
-	// Done indicates if we're still waiting on dial responses
-	var done bool
+// connect to all SMTP hosts concurrently and return the first connection
+// which completes the SMTP greeting, ignoring MX priority. The dials still
+// in flight are then cancelled and their connections closed, so a slow
+// host doesn't hold on to resources once a fast one answered.
+func newSMTPClientFirstConnected(mxRecords []*net.MX, opts dialOptions) (*smtp.Client, *net.MX, error) {
+	if len(mxRecords) == 0 {
+		return nil, nil, errors.New("No MX records found")
+	}
+	ctx, cancel := context.WithCancel(opts.context())
+	defer cancel()
+	opts.ctx = ctx
 
-	// mutex for data race
-	var mutex sync.Mutex
+	// buffered so that the dials finishing after the winner never block
+	ch := make(chan mxDialResult, len(mxRecords))
 
 	// Attempt to connect to all SMTP hosts concurrently
 	for i, r := range mxRecords {
-		addr := r.Host + smtpPort
-		index := i
+		mx := r
 		go func() {
-			c, err := dialSMTPFunc(addr, proxyURI, connectTimeout, operationTimeout)
-			if err != nil {
-				if !done {
-					ch <- err
-				}
-				return
-			}
-
-			// Place the client on the channel or close it
-			mutex.Lock()
-			switch {
-			case !done:
-				done = true
-				ch <- c
-				selectedMXCh <- mxRecords[index]
-			default:
-				c.Close()
-			}
-			mutex.Unlock()
+			c, err := dialHost(mx.Host+smtpPort, opts)
+			ch <- mxDialResult{client: c, mx: mx, index: i, err: err}
 		}()
 	}
 
-	// Collect errors or return a client
-	var errs []error
-	for {
+	// Collect errors or return a client, the error of the first record
+	// is returned whatever the order the dials failed in
+	errs := make([]error, len(mxRecords))
+	for failed := 0; failed < len(mxRecords); failed++ {
 		res := <-ch
-		switch r := res.(type) {
-		case *smtp.Client:
-			return r, <-selectedMXCh, nil
-		case error:
-			errs = append(errs, r)
-			if len(errs) == len(mxRecords) {
-				return nil, nil, errs[0]
-			}
-		default:
-			return nil, nil, errors.New("Unexpected response dialing SMTP server")
+		if res.err == nil {
+			// close the connections of the losers once their dials are cancelled
+			go closeLosers(ch, len(mxRecords)-failed-1)
+			return res.client, res.mx, nil
+		}
+		errs[res.index] = res.err
+	}
+	return nil, nil, errs[0]
+}
+
+// mxDialResult is the outcome of dialing a single MX host
+type mxDialResult struct {
+	client *smtp.Client
+	mx     *net.MX
+	index  int // index of mx in the dialed records
+	err    error
+}
+
+// closeLosers closes the connections of the n dials remaining on ch
+func closeLosers(ch <-chan mxDialResult, n int) {
+	for i := 0; i < n; i++ {
+		if res := <-ch; res.client != nil {
+			_ = res.client.Close()
 		}
 	}
 }
 
+// limitMXRecords returns the n most preferred records, all of them when n <= 0
+func limitMXRecords(records []*net.MX, n int) []*net.MX {
+	if n <= 0 || len(records) <= n {
+		return records
+	}
+	sorted := make([]*net.MX, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pref < sorted[j].Pref })
+	return sorted[:n]
+}
+
 // newSMTPClientPriority respects MX priority. It groups MX records by
 // preference (lowest value first). Within each group, it dials all hosts
 // concurrently and returns the first successful connection. It only falls back
 // to the next priority group if all hosts in the current group fail.
-func newSMTPClientPriority(mxRecords []*net.MX, proxyURI string, connectTimeout, operationTimeout time.Duration) (*smtp.Client, *net.MX, error) {
+// The records are ordered and deduplicated by sortMXRecords first, so the
+// fallback is the same across runs, unless they were shuffled for load spreading.
+func newSMTPClientPriority(mxRecords []*net.MX, opts dialOptions) (*smtp.Client, *net.MX, error) {
+	if !opts.mxLoadSpreading {
+		mxRecords = sortMXRecords(mxRecords)
+	}
 	var allErrs []error
 
 	for i := 0; i < len(mxRecords); {
@@ -234,7 +1074,7 @@ func newSMTPClientPriority(mxRecords []*net.MX, proxyURI string, connectTimeout,
 		}
 
 		group := mxRecords[start:i]
-		client, mx, err := newSMTPClientFirstConnected(group, proxyURI, connectTimeout, operationTimeout)
+		client, mx, err := newSMTPClientFirstConnected(group, opts)
 		if err == nil && client != nil {
 			return client, mx, nil
 		}
@@ -249,64 +1089,244 @@ func newSMTPClientPriority(mxRecords []*net.MX, proxyURI string, connectTimeout,
 	return nil, nil, errors.New("failed to connect to any MX server")
 }
 
+// sortMXRecords returns the records sorted by preference, the hosts of equal
+// preference sorted by name, as the order of the lookup is unspecified. A host
+// listed more than once is only kept at its lowest preference.
+func sortMXRecords(records []*net.MX) []*net.MX {
+	sorted := make([]*net.MX, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Pref != sorted[j].Pref {
+			return sorted[i].Pref < sorted[j].Pref
+		}
+		return mxHostKey(sorted[i].Host) < mxHostKey(sorted[j].Host)
+	})
+
+	seen := make(map[string]bool, len(sorted))
+	ret := sorted[:0]
+	for _, r := range sorted {
+		if key := mxHostKey(r.Host); !seen[key] {
+			seen[key] = true
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// shuffleMXRecords returns the records of sortMXRecords with the hosts of equal
+// preference in random order, so the checks spread over them
+func shuffleMXRecords(records []*net.MX) []*net.MX {
+	sorted := sortMXRecords(records)
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].Pref == sorted[start].Pref {
+			end++
+		}
+		group := sorted[start:end]
+		rand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] }) //nolint:gosec
+		start = end
+	}
+	return sorted
+}
+
+// mxHostKey is the case-insensitive name of an MX host without its trailing dot
+func mxHostKey(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// dialOptions configures how SMTP connections are established
+type dialOptions struct {
+	ctx              context.Context // bounds the wait for the rate limiter and the dial, Background when nil
+	proxyURI         string          // proxy to connect through, connects directly when empty
+	proxyFromEnv     bool            // connect through the proxy of the environment when proxyURI is empty
+	connectTimeout   time.Duration   // timeout for establishing connections
+	operationTimeout time.Duration   // timeout for SMTP operations
+	transcript       *transcript     // records the SMTP conversation when not nil
+	limiter          *hostLimiter    // rate limits the connections to each host when not nil
+	logger           Logger          // receives log entries when not nil
+	pool             *smtpPool       // reuses the clients of previous checks when not nil
+	dialer           DialFunc        // connects instead of the direct or proxy connection when not nil
+	helloName        string          // HELO/EHLO name of the check, the clients of the pool are keyed by it
+	fromEmail        string          // MAIL FROM address of the check
+	maxMXAttempts    int             // number of most preferred MX hosts dialed, all of them when <= 0
+	ipPreference     IPPreference    // IP families of the direct connections
+	expectedSize     int             // SIZE declared in MAIL FROM when the server supports it, none when <= 0
+	mxLoadSpreading  bool            // dial the MX hosts of equal preference in random order
+	timings          *dialTimings    // records the dial and greeting of the clients when not nil
+	maxCNAMEDepth    int             // number of CNAMEs followed by the MX lookup, the system resolver is used when <= 0
+	mxRecords        []*net.MX       // MX records of the domain already looked up, e.g. by the mx check, looked up when empty
+}
+
+// with returns the options overridden by the non-zero fields of overrides
+func (o dialOptions) with(overrides SMTPOptions) dialOptions {
+	if overrides.FromEmail != "" {
+		o.fromEmail = overrides.FromEmail
+	}
+	if overrides.HelloName != "" {
+		o.helloName = overrides.HelloName
+	}
+	if overrides.Proxy != "" {
+		o.proxyURI = overrides.Proxy
+	}
+	if overrides.ConnectTimeout > 0 {
+		o.connectTimeout = overrides.ConnectTimeout
+	}
+	if overrides.OperationTimeout > 0 {
+		o.operationTimeout = overrides.OperationTimeout
+	}
+	if overrides.ExpectedSize > 0 {
+		o.expectedSize = overrides.ExpectedSize
+	}
+	return o
+}
+
+// context returns the context bounding the dial, Background when none is set
+func (o dialOptions) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// dialOptions returns the dial options configured on the verifier, without proxy
+func (v *Verifier) dialOptions() dialOptions {
+	return dialOptions{
+		connectTimeout:   v.connectTimeout,
+		operationTimeout: v.operationTimeout,
+		limiter:          v.limiter,
+		logger:           v.logger,
+		dialer:           v.dialer,
+		maxMXAttempts:    v.maxMXAttempts,
+		ipPreference:     v.ipPreference,
+		proxyFromEnv:     v.proxyFromEnvironment,
+		mxLoadSpreading:  v.mxLoadSpreading,
+		maxCNAMEDepth:    v.maxCNAMEDepth,
+	}
+}
+
+// dialHost dials the SMTP server at addr once the rate limiter allows a connection
+// to its host, the limit of the host is tightened when it throttles the connection
+func dialHost(addr string, opts dialOptions) (*smtp.Client, error) {
+	host, _, _ := net.SplitHostPort(addr)
+	if err := opts.limiter.wait(opts.ctx, host); err != nil {
+		return nil, err
+	}
+	client, err := dialSMTPFunc(addr, opts)
+	if isThrottled(err) {
+		opts.limiter.tighten(host)
+	}
+	return client, err
+}
+
 // dialSMTP is a timeout wrapper for smtp.Dial. It attempts to dial an
 // SMTP server (socks5 proxy supported) and fails with a timeout if timeout is reached while
 // attempting to establish a new connection
-func dialSMTP(addr, proxyURI string, connectTimeout, operationTimeout time.Duration) (*smtp.Client, error) {
+func dialSMTP(addr string, opts dialOptions) (*smtp.Client, error) {
 	// Dial the new smtp connection
 	var conn net.Conn
 	var err error
 
-	if proxyURI != "" {
-		conn, err = establishProxyConnection(addr, proxyURI, connectTimeout)
-	} else {
-		conn, err = establishConnection(addr, connectTimeout)
+	ctx := opts.context()
+	host, _, _ := net.SplitHostPort(addr)
+	proxyURI := opts.proxyURI
+	if proxyURI == "" && opts.proxyFromEnv {
+		proxyURI = environmentProxy(host)
+	}
+	dialStart := time.Now()
+	switch {
+	case opts.dialer != nil:
+		conn, err = establishDialerConnection(ctx, addr, opts.dialer, opts.connectTimeout)
+	case proxyURI != "":
+		conn, err = establishProxyConnection(ctx, addr, proxyURI, opts.connectTimeout)
+	default:
+		conn, err = establishConnection(ctx, addr, opts.connectTimeout, opts.ipPreference)
 	}
 	if err != nil {
 		return nil, err
 	}
+	dialElapsed := time.Since(dialStart)
+
+	if family := ipFamily(conn.RemoteAddr()); family != "" {
+		remote := conn.RemoteAddr().String()
+		opts.transcript.add(host, transcriptNotePrefix+"connected to "+remote+" over "+family)
+		if opts.logger != nil {
+			opts.logger.Debug("connected to MX host", "host", host, "addr", remote, "family", family)
+		}
+	}
 
 	// Set specific timeouts for writing and reading
-	err = conn.SetDeadline(time.Now().Add(operationTimeout))
+	err = conn.SetDeadline(time.Now().Add(opts.operationTimeout))
 	if err != nil {
+		_ = conn.Close()
 		return nil, err
 	}
 
-	host, _, _ := net.SplitHostPort(addr)
-	return smtp.NewClient(conn, host)
+	// the greeting is abandoned when ctx is done, e.g. another MX host answered first
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+
+	client, err := newSMTPClientOverConn(conn, host, opts)
+	if !stop() {
+		// the connection was closed by ctx
+		return nil, ctx.Err()
+	}
+	if err == nil {
+		opts.timings.dial(client, dialElapsed)
+	}
+	return client, err
+}
+
+// newSMTPClientOverConn returns a new SMTP client speaking over an established conn,
+// the greeting of the server is read before returning
+func newSMTPClientOverConn(conn net.Conn, host string, opts dialOptions) (*smtp.Client, error) {
+	if opts.transcript != nil {
+		conn = newTranscriptConn(conn, opts.transcript, host)
+	}
+	start := time.Now()
+	client, err := smtp.NewClient(conn, host)
+	if err == nil {
+		opts.pool.track(client, conn)
+		opts.timings.greeting(client, start)
+	}
+	return client, err
 }
 
 // GenerateRandomEmail generates a random email address using the domain passed. Used
 // primarily for checking the existence of a catch-all address
 func GenerateRandomEmail(domain string) string {
-	r := make([]byte, 32)
-	for i := 0; i < 32; i++ {
-		r[i] = alphanumeric[rand.Intn(len(alphanumeric))] //nolint:gosec
-	}
-	return fmt.Sprintf("%s@%s", string(r), domain)
+	return fmt.Sprintf("%s@%s", ProbeStyleAlphanumeric.localPart(), domain)
+}
 
+// establishConnection connects to the address over the IP families of pref,
+// the dial is abandoned when ctx is done
+func establishConnection(ctx context.Context, addr string, timeout time.Duration, pref IPPreference) (net.Conn, error) {
+	return dialPreferred(ctx, addr, timeout, pref)
 }
 
-// establishConnection connects to the address on the named network address.
-func establishConnection(addr string, timeout time.Duration) (net.Conn, error) {
-	return net.DialTimeout("tcp", addr, timeout)
+// establishDialerConnection connects to the address with the dialer set by WithSMTPDialer
+func establishDialerConnection(ctx context.Context, addr string, dialer DialFunc, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return dialer(ctx, "tcp", addr)
 }
 
 // establishProxyConnection connects to the address on the named network address
-// via proxy protocol
-func establishProxyConnection(addr, proxyURI string, timeout time.Duration) (net.Conn, error) {
+// via proxy protocol, SOCKS or HTTP CONNECT
+func establishProxyConnection(ctx context.Context, addr, proxyURI string, timeout time.Duration) (net.Conn, error) {
 	u, err := url.Parse(proxyURI)
 	if err != nil {
 		return nil, err
 	}
-	dialer, err := proxy.FromURL(u, nil)
-	if err != nil {
-		return nil, err
-	}
 
 	// https://github.com/golang/go/issues/37549#issuecomment-1178745487
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	if isHTTPProxy(u) {
+		return dialHTTPProxy(ctx, u, addr)
+	}
+	dialer, err := proxy.FromURL(u, nil)
+	if err != nil {
+		return nil, err
+	}
 	return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
 }
@@ -1,12 +1,194 @@
 package emailverifier
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
 const (
 	YAHOO = "yahoo"
+	GMAIL = "gmail"
 )
 
 type smtpAPIVerifier interface {
 	// isSupported the specific host supports the check by api.
 	isSupported(host string) bool
-	// check must be called before isSupported == true
-	check(domain, username string) (*SMTP, error)
+	// check must be called before isSupported == true, the HTTP requests are
+	// bounded by ctx and made with opts
+	check(ctx context.Context, domain, username string, opts apiOptions) (*SMTP, error)
+}
+
+// DefaultAPIUserAgent is the User-Agent of the requests of the API verifiers which
+// don't mimic a browser, unless set by APIHeaders
+var DefaultAPIUserAgent = "email-verifier/" + Version
+
+const (
+	defaultAPITimeout = 10 * time.Second       // timeout of each HTTP request of the API verifiers
+	apiMaxRetries     = 2                      // retries of an API request answered with 429 or 5xx
+	apiMaxRetryAfter  = 30 * time.Second       // longest Retry-After waited for, longer ones aren't retried
+	apiRetryBackoff   = 500 * time.Millisecond // delay before the first retry, doubled on each retry
+)
+
+// apiHTTPClient is the HTTP client shared by the API verifiers
+var apiHTTPClient = &http.Client{}
+
+// apiOptions configures the HTTP requests of the API verifiers
+type apiOptions struct {
+	timeout    time.Duration // timeout of each request, defaultAPITimeout when <= 0
+	maxRetries int           // retries of a request answered with 429 or 5xx
+	backoff    time.Duration // delay before the first retry, doubled on each retry
+	header     http.Header   // headers set on each request, over the headers of the verifier
+}
+
+// apiOptions returns the options of the API verifiers configured on the verifier
+func (v *Verifier) apiOptions() apiOptions {
+	return apiOptions{timeout: v.apiTimeout, maxRetries: apiMaxRetries, backoff: apiRetryBackoff, header: v.apiHeaders}
+}
+
+// setHeaders sets the headers of opts on request, which is sent with
+// DefaultAPIUserAgent when neither sets a User-Agent
+func (opts apiOptions) setHeaders(request *http.Request) {
+	for name, values := range opts.header {
+		request.Header.Del(name)
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	if request.Header.Get("User-Agent") == "" {
+		request.Header.Set("User-Agent", DefaultAPIUserAgent)
+	}
+}
+
+// doAPIRequest sends the request built by newRequest with client, each attempt bounded
+// by the timeout of opts. A 429 or 5xx response is retried with backoff, a 429 waits for
+// its Retry-After instead. Once the retries are exhausted, the failure is returned as a
+// LookupError like the errors of the SMTP check: ErrTryAgainLater for a 429,
+// ErrServerUnavailable for a 5xx and the parsed error of a failed request otherwise.
+// The response body must be closed.
+func doAPIRequest(ctx context.Context, client *http.Client, opts apiOptions, vendor string, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	timeout := opts.timeout
+	if timeout <= 0 {
+		timeout = defaultAPITimeout
+	}
+	delay := opts.backoff
+
+	for attempt := 0; ; attempt++ {
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		request, err := newRequest(reqCtx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		opts.setHeaders(request)
+		resp, err := client.Do(request)
+		if err != nil {
+			cancel()
+			return nil, apiRequestError(vendor, err)
+		}
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError {
+			resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		cancel()
+
+		lookupErr := newLookupError(ErrServerUnavailable, fmt.Sprintf("%s check by api, unexpected status_code: %d", vendor, resp.StatusCode))
+		wait := delay
+		if resp.StatusCode == http.StatusTooManyRequests {
+			lookupErr = newLookupError(ErrTryAgainLater, vendor+" check by api, rate limited")
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+		}
+		if attempt >= opts.maxRetries || wait > apiMaxRetryAfter {
+			return nil, lookupErr
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, lookupErr
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// apiRequestError returns the LookupError of an API request of vendor which failed with err
+func apiRequestError(vendor string, err error) *LookupError {
+	err = fmt.Errorf("%s check by api: %w", vendor, err)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return newLookupError(ErrTimeout, err.Error())
+	}
+	return ParseSMTPError(err)
+}
+
+// parseRetryAfter parses the delay of a Retry-After header, given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(date)), true
+	}
+	return 0, false
+}
+
+// cancelOnClose cancels the context of a response when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// newAPIVerifier creates the API verifier of the given vendor
+func newAPIVerifier(name string) (smtpAPIVerifier, error) {
+	switch name {
+	case YAHOO:
+		return newYahooAPIVerifier(apiHTTPClient), nil
+	case GMAIL:
+		return newGmailAPIVerifier(apiHTTPClient), nil
+	default:
+		return nil, fmt.Errorf("unsupported to enable the API verifier for vendor: %s", name)
+	}
+}
+
+// apiVerifierFor returns the API verifier mapped to domain or the enabled one
+// supporting one of its MX hosts, or nil when the domain must be checked by SMTP.
+// The MX lookup is bounded by ctx.
+func (v *Verifier) apiVerifierFor(ctx context.Context, domain string) smtpAPIVerifier {
+	if apiVerifier, ok := v.apiDomains[cleanDomain(domain)]; ok {
+		return apiVerifier
+	}
+	if len(v.apiVerifiers) == 0 {
+		return nil
+	}
+	mxRecords, err := lookupMXContext(ctx, domainToASCII(domain))
+	if err != nil {
+		return nil
+	}
+	for _, mx := range mxRecords {
+		for _, apiVerifier := range v.apiVerifiers {
+			if apiVerifier.isSupported(strings.ToLower(mx.Host)) {
+				return apiVerifier
+			}
+		}
+	}
+	return nil
 }
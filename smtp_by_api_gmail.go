@@ -0,0 +1,64 @@
+package emailverifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const gmailLookupEndpoint = "https://mail.google.com/mail/gxlu"
+
+// Check gmail email exists by the gxlu endpoint of gmail, which sets a session
+// cookie only when the account exists. This is best-effort: the endpoint is
+// undocumented and may change its behavior at any time. Google rate limits the
+// endpoint, a rate limited request (429) is retried and then fails with ErrTryAgainLater
+// rather than reporting the mailbox as not deliverable.
+func newGmailAPIVerifier(client *http.Client) smtpAPIVerifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return gmail{
+		client: client,
+	}
+}
+
+type gmail struct {
+	client *http.Client
+}
+
+func (g gmail) isSupported(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	return strings.HasSuffix(host, ".google.com") || strings.HasSuffix(host, ".googlemail.com")
+}
+
+func (g gmail) check(ctx context.Context, domain, username string, opts apiOptions) (*SMTP, error) {
+	endpoint := gmailLookupEndpoint + "?email=" + url.QueryEscape(username+"@"+domain)
+	resp, err := doAPIRequest(ctx, g.client, opts, GMAIL, func(ctx context.Context) (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Add("User-Agent", userAgent)
+		return request, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		details := fmt.Sprintf("gmail check by api, unexpected status_code: %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusForbidden {
+			// the endpoint refuses the requests of this client, e.g. its IP address
+			return nil, newLookupError(ErrBlocked, details)
+		}
+		return nil, newLookupError(ErrServerUnavailable, details)
+	}
+
+	return &SMTP{
+		HostExists:  true,
+		Deliverable: len(resp.Cookies()) > 0,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package emailverifier
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestGmailCheckByAPI(t *testing.T) {
+	defer gock.Off()
+	gmailAPIVerifier := newGmailAPIVerifier(nil)
+
+	t.Run("email exists", func(tt *testing.T) {
+		gock.New("https://mail.google.com").
+			Get("/mail/gxlu").
+			MatchParam("email", "someone@gmail.com").
+			Reply(http.StatusNoContent).
+			SetHeader("Set-Cookie", "COMPASS=gmail=abc; Path=/mail")
+		res, err := gmailAPIVerifier.check(context.Background(), "gmail.com", "someone", apiOptions{})
+		assert.NoError(tt, err)
+		assert.Equal(tt, &SMTP{HostExists: true, Deliverable: true}, res)
+	})
+	t.Run("email not exists", func(tt *testing.T) {
+		gock.New("https://mail.google.com").
+			Get("/mail/gxlu").
+			Reply(http.StatusNoContent)
+		res, err := gmailAPIVerifier.check(context.Background(), "gmail.com", "123", apiOptions{})
+		assert.NoError(tt, err)
+		assert.Equal(tt, &SMTP{HostExists: true, Deliverable: false}, res)
+	})
+	t.Run("rate limited", func(tt *testing.T) {
+		gock.New("https://mail.google.com").
+			Get("/mail/gxlu").
+			Reply(http.StatusTooManyRequests)
+		res, err := gmailAPIVerifier.check(context.Background(), "gmail.com", "someone", apiOptions{})
+		assert.Nil(tt, res)
+		if assert.Error(tt, err) {
+			assert.Equal(tt, ErrTryAgainLater, err.(*LookupError).Message)
+		}
+	})
+	t.Run("client errors", func(tt *testing.T) {
+		for status, message := range map[int]string{
+			http.StatusForbidden: ErrBlocked,
+			http.StatusNotFound:  ErrServerUnavailable,
+		} {
+			gock.New("https://mail.google.com").
+				Get("/mail/gxlu").
+				Reply(status)
+			res, err := gmailAPIVerifier.check(context.Background(), "gmail.com", "someone", apiOptions{})
+			assert.Nil(tt, res)
+			var lookupErr *LookupError
+			if assert.ErrorAs(tt, err, &lookupErr) {
+				assert.Equal(tt, message, lookupErr.Message)
+				assert.Contains(tt, lookupErr.Details, "unexpected status_code")
+			}
+		}
+	})
+}
+
+func TestGmailIsSupported(t *testing.T) {
+	g := gmail{}
+	assert.True(t, g.isSupported("gmail-smtp-in.l.google.com."))
+	assert.True(t, g.isSupported("aspmx.l.google.com"))
+	assert.False(t, g.isSupported("google.com.evil.example."))
+	assert.False(t, g.isSupported("mx.yahoo.com."))
+}
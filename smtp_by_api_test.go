@@ -0,0 +1,127 @@
+package emailverifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoAPIRequest_Retries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	opts := apiOptions{timeout: time.Second, maxRetries: 2, backoff: time.Millisecond}
+	resp, err := doAPIRequest(context.Background(), srv.Client(), opts, GMAIL, newGetRequest(srv.URL))
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDoAPIRequest_Errors(t *testing.T) {
+	status := http.StatusBadGateway
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status == 0 {
+			time.Sleep(100 * time.Millisecond)
+			return
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "3600")
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	opts := apiOptions{timeout: time.Second, maxRetries: 1, backoff: time.Millisecond}
+
+	_, err := doAPIRequest(context.Background(), srv.Client(), opts, GMAIL, newGetRequest(srv.URL))
+	if assert.IsType(t, &LookupError{}, err) {
+		assert.Equal(t, ErrServerUnavailable, err.(*LookupError).Message)
+		assert.True(t, err.(*LookupError).Retryable())
+	}
+
+	// a Retry-After longer than apiMaxRetryAfter isn't waited for
+	status = http.StatusTooManyRequests
+	start := time.Now()
+	_, err = doAPIRequest(context.Background(), srv.Client(), opts, GMAIL, newGetRequest(srv.URL))
+	assert.Less(t, time.Since(start), time.Second)
+	if assert.IsType(t, &LookupError{}, err) {
+		assert.Equal(t, ErrTryAgainLater, err.(*LookupError).Message)
+	}
+
+	status = 0
+	opts.timeout = 10 * time.Millisecond
+	_, err = doAPIRequest(context.Background(), srv.Client(), opts, GMAIL, newGetRequest(srv.URL))
+	if assert.IsType(t, &LookupError{}, err) {
+		assert.Equal(t, ErrTimeout, err.(*LookupError).Message)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := parseRetryAfter("120")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, d)
+
+	d, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, d, float64(2*time.Second))
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+// newGetRequest returns a request builder of a GET of url
+func newGetRequest(url string) func(ctx context.Context) (*http.Request, error) {
+	return func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}
+}
+
+func TestDoAPIRequest_Headers(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	send := func(opts apiOptions, userAgent string) {
+		resp, err := doAPIRequest(context.Background(), srv.Client(), opts, GMAIL, func(ctx context.Context) (*http.Request, error) {
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			if err == nil && userAgent != "" {
+				request.Header.Set("User-Agent", userAgent)
+			}
+			return request, err
+		})
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+	}
+
+	// the default options keep the User-Agent of the verifier, or send the default one
+	send(apiOptions{}, "Mozilla/5.0")
+	assert.Equal(t, "Mozilla/5.0", header.Get("User-Agent"))
+	send(apiOptions{}, "")
+	assert.Equal(t, DefaultAPIUserAgent, header.Get("User-Agent"))
+
+	v := NewVerifier().APIHeaders(http.Header{"User-Agent": {"acme-verifier/2.0"}, "x-egress-token": {"secret"}})
+	send(v.apiOptions(), "Mozilla/5.0")
+	assert.Equal(t, "acme-verifier/2.0", header.Get("User-Agent"))
+	assert.Equal(t, "secret", header.Get("X-Egress-Token"))
+}
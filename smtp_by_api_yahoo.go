@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
-	"time"
 )
 
 const (
@@ -53,8 +52,8 @@ func (y yahoo) isSupported(host string) bool {
 	return strings.Contains(host, "yahoo")
 }
 
-func (y yahoo) check(domain, username string) (*SMTP, error) {
-	cookies, signUpPageRespBytes, err := y.toSignUpPage()
+func (y yahoo) check(ctx context.Context, domain, username string, opts apiOptions) (*SMTP, error) {
+	cookies, signUpPageRespBytes, err := y.toSignUpPage(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +71,7 @@ func (y yahoo) check(domain, username string) (*SMTP, error) {
 		return nil, errors.New("yahoo check by api, no sessionIndex")
 	}
 
-	yahooErrResp, err := y.sendValidateRequest(yahooValidateReq{
+	yahooErrResp, err := y.sendValidateRequest(ctx, opts, yahooValidateReq{
 		Domain:       domain,
 		Username:     username,
 		Acrumb:       acrumb,
@@ -110,7 +109,7 @@ func checkUsernameExists(resp yahooErrorResp) bool {
 	return false
 }
 
-func (y yahoo) sendValidateRequest(req yahooValidateReq) (yahooErrorResp, error) {
+func (y yahoo) sendValidateRequest(ctx context.Context, opts apiOptions, req yahooValidateReq) (yahooErrorResp, error) {
 	var res yahooErrorResp
 	data, err := json.Marshal(struct {
 		Acrumb       string `json:"acrumb"`
@@ -128,18 +127,18 @@ func (y yahoo) sendValidateRequest(req yahooValidateReq) (yahooErrorResp, error)
 	if err != nil {
 		return res, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, signupEndpoint, bytes.NewReader(data))
-	if err != nil {
-		return res, err
-	}
-	for _, c := range req.Cookies {
-		request.AddCookie(c)
-	}
-	request.Header.Add("X-Requested-With", "XMLHttpRequest")
-	request.Header.Add("Content-Type", "application/json; charset=UTF-8")
-	resp, err := y.client.Do(request)
+	resp, err := doAPIRequest(ctx, y.client, opts, YAHOO, func(ctx context.Context) (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, signupEndpoint, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range req.Cookies {
+			request.AddCookie(c)
+		}
+		request.Header.Add("X-Requested-With", "XMLHttpRequest")
+		request.Header.Add("Content-Type", "application/json; charset=UTF-8")
+		return request, nil
+	})
 	if err != nil {
 		return res, err
 	}
@@ -151,15 +150,15 @@ func (y yahoo) sendValidateRequest(req yahooValidateReq) (yahooErrorResp, error)
 	return res, json.Unmarshal(respBytes, &res)
 }
 
-func (y yahoo) toSignUpPage() ([]*http.Cookie, []byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, signupPage, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	request.Header.Add("User-Agent", userAgent)
-	resp, err := y.client.Do(request)
+func (y yahoo) toSignUpPage(ctx context.Context, opts apiOptions) ([]*http.Cookie, []byte, error) {
+	resp, err := doAPIRequest(ctx, y.client, opts, YAHOO, func(ctx context.Context) (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, signupPage, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Add("User-Agent", userAgent)
+		return request, nil
+	})
 	if err != nil {
 		return nil, nil, err
 	}
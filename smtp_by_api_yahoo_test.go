@@ -1,6 +1,7 @@
 package emailverifier
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -10,13 +11,13 @@ import (
 func TestYahooCheckByAPI(t *testing.T) {
 	yahooAPIVerifier := newYahooAPIVerifier(nil)
 	t.Run("email exists", func(tt *testing.T) {
-		res, err := yahooAPIVerifier.check("yahoo.com", "hello")
+		res, err := yahooAPIVerifier.check(context.Background(), "yahoo.com", "hello", apiOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, true, res.HostExists)
 		assert.Equal(t, true, res.Deliverable)
 	})
 	t.Run("invalid email not exists", func(tt *testing.T) {
-		res, err := yahooAPIVerifier.check("yahoo.com", "123")
+		res, err := yahooAPIVerifier.check(context.Background(), "yahoo.com", "123", apiOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, true, res.HostExists)
 		assert.Equal(t, false, res.Deliverable)
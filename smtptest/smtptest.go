@@ -0,0 +1,204 @@
+// Package smtptest provides an in-memory fake SMTP server to test code using
+// the email verifier without reaching the network, e.g. the handling of
+// catch-all domains or greylisting:
+//
+//	server := smtptest.SpawnFakeSMTP(
+//		smtptest.Reply{Prefix: "RCPT TO:<user@", Reply: "250 OK"},
+//		smtptest.Reply{Prefix: "RCPT TO:", Reply: "550 5.1.1 user unknown"},
+//	)
+//	defer server.Close()
+//	verifier := emailverifier.NewVerifier().EnableSMTPCheck().WithSMTPDialer(server.Dial)
+//	ret, err := verifier.CheckSMTPWithMX("mx.example.com", "example.com", "user")
+package smtptest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Greeting is the greeting sent by the fake server on every connection
+const Greeting = "220 fake.smtptest ESMTP"
+
+// Reply is a canned reply of the fake server
+type Reply struct {
+	Prefix string // the reply answers the commands starting with Prefix, matched case-insensitively
+	Reply  string // full reply including its code, e.g. "450 4.7.1 Greylisted", lines are separated by "\r\n"
+}
+
+// Server is an in-memory fake SMTP server, it is safe for concurrent use.
+// Commands without a matching Reply are answered with "250 OK", EHLO with
+// the 8BITMIME extension and QUIT with "221 Bye". The connection is closed
+// after a 421 reply, like real servers do.
+type Server struct {
+	Listener net.Listener // accepts the connections dialed by Dial
+
+	replies  []Reply
+	mu       sync.Mutex
+	commands []string
+	wg       sync.WaitGroup
+}
+
+// SpawnFakeSMTP starts a fake SMTP server answering with replies,
+// the first Reply matching a command is used. Close it when done.
+func SpawnFakeSMTP(replies ...Reply) *Server {
+	s := &Server{
+		Listener: newPipeListener(),
+		replies:  replies,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Dial connects to the fake server whatever addr is, it is meant to be passed
+// to Verifier.WithSMTPDialer
+func (s *Server) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return s.Listener.(*pipeListener).dial(ctx)
+}
+
+// Commands returns the commands received by the server, in order
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Close stops the server and closes its connections
+func (s *Server) Close() error {
+	err := s.Listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// serve accepts connections until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle runs the SMTP conversation of a connection
+func (s *Server) handle(conn net.Conn) {
+	// the connection is closed when the conversation ends or the server is closed
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-s.Listener.(*pipeListener).done:
+		case <-finished:
+		}
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	write := func(reply string) bool {
+		_, err := conn.Write([]byte(reply + "\r\n"))
+		return err == nil
+	}
+	if !write(Greeting) {
+		return
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+
+		reply := s.replyTo(cmd)
+		if !write(reply) || strings.HasPrefix(reply, "421") || strings.HasPrefix(strings.ToUpper(cmd), "QUIT") {
+			return
+		}
+	}
+}
+
+// replyTo returns the reply to cmd
+func (s *Server) replyTo(cmd string) string {
+	upper := strings.ToUpper(cmd)
+	for _, r := range s.replies {
+		if strings.HasPrefix(upper, strings.ToUpper(r.Prefix)) {
+			return r.Reply
+		}
+	}
+	switch {
+	case strings.HasPrefix(upper, "EHLO"):
+		return "250-fake.smtptest\r\n250 8BITMIME"
+	case strings.HasPrefix(upper, "QUIT"):
+		return "221 Bye"
+	default:
+		return "250 OK"
+	}
+}
+
+// errListenerClosed is returned when dialing or accepting on a closed pipeListener
+var errListenerClosed = errors.New("smtptest: listener closed")
+
+// pipeListener is an in-memory net.Listener, its connections are net.Pipe
+type pipeListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// dial returns the client end of a new connection, the server end is accepted by Accept
+func (l *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		return nil, errListenerClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Accept implements net.Listener
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, errListenerClosed
+	}
+}
+
+// Close implements net.Listener
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+// Addr implements net.Listener
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// pipeAddr is the address of a pipeListener
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "smtptest" }
@@ -0,0 +1,55 @@
+package smtptest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	emailverifier "github.com/AfterShip/email-verifier"
+	"github.com/AfterShip/email-verifier/smtptest"
+)
+
+func TestSpawnFakeSMTP_CatchAll(t *testing.T) {
+	server := smtptest.SpawnFakeSMTP(
+		smtptest.Reply{Prefix: "RCPT TO:<user@", Reply: "250 OK"},
+		smtptest.Reply{Prefix: "rcpt to:", Reply: "550 5.1.1 user unknown"},
+	)
+	defer server.Close()
+
+	v := emailverifier.NewVerifier().EnableSMTPCheck().WithSMTPDialer(server.Dial)
+	ret, err := v.CheckSMTPWithMX("mx.example.com", "example.com", "user")
+	assert.NoError(t, err)
+	assert.True(t, ret.Deliverable)
+	assert.Equal(t, emailverifier.CatchAllNo, ret.CatchAllStatus)
+
+	ret, err = v.CheckSMTPWithMX("mx.example.com", "example.com", "nobody")
+	assert.NoError(t, err)
+	assert.False(t, ret.Deliverable)
+
+	assert.Contains(t, server.Commands(), "RCPT TO:<user@example.com>")
+	assert.Contains(t, server.Commands(), "QUIT")
+}
+
+func TestSpawnFakeSMTP_Greylisting(t *testing.T) {
+	server := smtptest.SpawnFakeSMTP(
+		smtptest.Reply{Prefix: "RCPT", Reply: "450 4.7.1 Recipient address rejected: Greylisted for 5 minutes"},
+	)
+	defer server.Close()
+
+	v := emailverifier.NewVerifier().EnableSMTPCheck().DisableCatchAllCheck().WithSMTPDialer(server.Dial)
+	_, err := v.CheckSMTPWithMX("mx.example.com", "example.com", "user")
+	var lookupErr *emailverifier.LookupError
+	if assert.True(t, errors.As(err, &lookupErr)) {
+		assert.True(t, lookupErr.Retryable())
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	server := smtptest.SpawnFakeSMTP()
+	assert.NoError(t, server.Close())
+
+	_, err := server.Dial(context.Background(), "tcp", "mx.example.com:25")
+	assert.Error(t, err)
+}
@@ -23,7 +23,13 @@ func (v *Verifier) SuggestDomain(domain string) string {
 
 	}
 
-	closestDomain := findClosestDomain(domain, freeDomains, domainThreshold)
+	// A known domain with a mistyped TLD (e.g. "gmail.con") is corrected on the TLD alone,
+	// the full domain distance misses transpositions such as "gmail.ocm"
+	if suggestion := suggestTopLevelDomain(domain, loadedFreeDomains()); suggestion != "" {
+		return suggestion
+	}
+
+	closestDomain := findClosestDomain(domain, loadedFreeDomains(), domainThreshold)
 	if closestDomain != "" {
 		if closestDomain == domain {
 			// The domain exactly matches one of the suggestion domains, no suggestion provided.
@@ -83,3 +89,39 @@ func findClosestDomain(domain string, domains map[string]bool, threshold float32
 
 	return ""
 }
+
+// suggestTopLevelDomain suggests the domain of domains sharing all the labels of domain
+// but its TLD, whose TLD (e.g. "com", "co.uk") is the closest to the TLD of domain by
+// OSA Damerau-Levenshtein distance, at most topLevelMaxDistance away. Ties prefer "com",
+// then the lowest TLD in lexical order. It is empty when domain is in domains or none is close.
+func suggestTopLevelDomain(domain string, domains map[string]bool) string {
+	i := strings.LastIndex(domain, ".")
+	if i <= 0 || domains[domain] {
+		return ""
+	}
+	prefix, tld := domain[:i+1], domain[i+1:]
+
+	best, bestDist := "", topLevelMaxDistance+1
+	for d := range domains {
+		candidate, ok := strings.CutPrefix(d, prefix)
+		if !ok || candidate == "" {
+			continue
+		}
+		dist := edlib.OSADamerauLevenshteinDistance(tld, candidate)
+		if dist < bestDist || dist == bestDist && preferTopLevelDomain(candidate, best) {
+			best, bestDist = candidate, dist
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return prefix + best
+}
+
+// preferTopLevelDomain reports whether the TLD a is preferred over b among TLDs as close to a typo
+func preferTopLevelDomain(a, b string) bool {
+	if a == "com" || b == "com" {
+		return a == "com"
+	}
+	return a < b
+}
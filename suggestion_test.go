@@ -47,3 +47,33 @@ func TestSuggestDomainOK_SLD(t *testing.T) {
 	ret := verifier.SuggestDomain(domain)
 	assert.Equal(t, "hotmail.aftership", ret)
 }
+
+func TestSuggestDomainOK_TLDTypos(t *testing.T) {
+	cases := map[string]string{
+		"gmail.con":    "gmail.com",
+		"gmail.cm":     "gmail.com",
+		"gmail.ocm":    "gmail.com",
+		"gmail.vom":    "gmail.com",
+		"gmail.cmo":    "gmail.com",
+		"hotmail.con":  "hotmail.com",
+		"yahoo.co":     "yahoo.com",
+		"yahoo.ocm":    "yahoo.com",
+		"outlook.comm": "outlook.com",
+		"icloud.xom":   "icloud.com",
+	}
+	for domain, want := range cases {
+		assert.Equal(t, want, verifier.SuggestDomain(domain), domain)
+	}
+}
+
+func TestSuggestTopLevelDomain(t *testing.T) {
+	domains := map[string]bool{"mail.example": true, "mail.example.co.uk": true, "mail.ca": true, "mail.com": true}
+
+	assert.Equal(t, "mail.com", suggestTopLevelDomain("mail.cm", domains))
+	assert.Equal(t, "mail.example.co.uk", suggestTopLevelDomain("mail.example.co.uj", domains))
+	// known domains and unknown prefixes aren't corrected
+	assert.Equal(t, "", suggestTopLevelDomain("mail.ca", domains))
+	assert.Equal(t, "", suggestTopLevelDomain("other.con", domains))
+	// TLDs too far from any known one aren't corrected
+	assert.Equal(t, "", suggestTopLevelDomain("mail.info", domains))
+}
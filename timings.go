@@ -0,0 +1,78 @@
+package emailverifier
+
+import (
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+// SMTPTimings are the durations of the stages of an SMTP check, recorded in SMTP.Timings
+// when EnableTimings is set. They are measured with the monotonic clock, a stage which
+// was skipped is zero, e.g. the dial, greeting and EHLO of a pooled connection. The
+// stages of a reconnection after the catch-all probe are added to the first connection.
+type SMTPTimings struct {
+	Dial         time.Duration `json:"dial"`           // connecting to the MX host, through the proxy if any
+	Greeting     time.Duration `json:"greeting"`       // waiting for the greeting of the server
+	EHLO         time.Duration `json:"ehlo"`           // EHLO, including the HELO fallback
+	STARTTLS     time.Duration `json:"starttls"`       // STARTTLS, only negotiated with a submission relay
+	MailFrom     time.Duration `json:"mail_from"`      // MAIL FROM
+	CatchAllRCPT time.Duration `json:"catch_all_rcpt"` // RCPT of the catch-all probes, summed over the probes
+	TargetRCPT   time.Duration `json:"target_rcpt"`    // RCPT of the checked address
+}
+
+// dialTimings records the dial and greeting durations of the clients dialed by a single
+// check, the MX hosts of the check may be dialed concurrently. A nil dialTimings records nothing.
+type dialTimings struct {
+	mu      sync.Mutex
+	clients map[*smtp.Client]SMTPTimings
+}
+
+// newDialTimings creates an empty dialTimings
+func newDialTimings() *dialTimings {
+	return &dialTimings{clients: map[*smtp.Client]SMTPTimings{}}
+}
+
+// greeting records the time elapsed since start as the greeting of client
+func (d *dialTimings) greeting(client *smtp.Client, start time.Time) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.clients[client]
+	t.Greeting = time.Since(start)
+	d.clients[client] = t
+}
+
+// dial records elapsed as the dial of client
+func (d *dialTimings) dial(client *smtp.Client, elapsed time.Duration) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.clients[client]
+	t.Dial = elapsed
+	d.clients[client] = t
+}
+
+// of returns the timings of a check on client starting with its dial and greeting,
+// which are zero when client wasn't dialed by the check. It is nil when d is nil.
+func (d *dialTimings) of(client *smtp.Client) *SMTPTimings {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.clients[client]
+	return &t
+}
+
+// addDial adds the dial and greeting of other, e.g. of a reconnection, to t
+func (t *SMTPTimings) addDial(other *SMTPTimings) {
+	if t == nil || other == nil {
+		return
+	}
+	t.Dial += other.Dial
+	t.Greeting += other.Greeting
+}
@@ -0,0 +1,149 @@
+package emailverifier
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+)
+
+const (
+	transcriptClientPrefix = "C: " // prefix of the commands sent to the server
+	transcriptServerPrefix = "S: " // prefix of the responses of the server
+	transcriptNotePrefix   = "* "  // prefix of the notes about the conversation
+	transcriptRedacted     = "<redacted>"
+)
+
+// transcript records the SMTP conversation of the connections dialed for a check.
+// It is safe for concurrent use since several MX hosts may be dialed at once,
+// every line is tagged with the host of its connection. All methods are no-ops
+// on a nil transcript.
+type transcript struct {
+	mu      sync.Mutex
+	lines   []transcriptLine
+	redacts []string // strings replaced by transcriptRedacted in the lines
+}
+
+// transcriptLine is a line of the SMTP conversation with a host
+type transcriptLine struct {
+	host string
+	text string
+}
+
+// add appends a line of the conversation with host
+func (t *transcript) add(host, text string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, transcriptLine{host: host, text: text})
+}
+
+// note appends a note about the conversation, notes aren't tied to a host
+// and are returned by linesOf for every host
+func (t *transcript) note(text string) {
+	t.add("", transcriptNotePrefix+text)
+}
+
+// redact replaces every occurrence of str in the lines returned by linesOf
+func (t *transcript) redact(str string) {
+	if t == nil || str == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.redacts = append(t.redacts, str)
+}
+
+// linesOf returns the lines of the conversation with host, or all lines when host is empty
+func (t *transcript) linesOf(host string) []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ret := make([]string, 0, len(t.lines))
+	for _, l := range t.lines {
+		if host != "" && l.host != "" && l.host != host {
+			continue
+		}
+		text := l.text
+		for _, r := range t.redacts {
+			text = strings.ReplaceAll(text, r, transcriptRedacted)
+		}
+		ret = append(ret, text)
+	}
+	return ret
+}
+
+// transcriptConn is a net.Conn recording every line read and written in a transcript
+type transcriptConn struct {
+	net.Conn
+	t       *transcript
+	host    string
+	read    []byte // partial line read but not recorded yet
+	written []byte // partial line written but not recorded yet
+	verb    string // verb of the last command written
+
+	encrypted bool // the conversation switched to TLS after STARTTLS, it isn't recorded anymore
+}
+
+// newTranscriptConn wraps conn to record its lines in t, tagged with host
+func newTranscriptConn(conn net.Conn, t *transcript, host string) *transcriptConn {
+	return &transcriptConn{Conn: conn, t: t, host: host}
+}
+
+func (c *transcriptConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if c.encrypted {
+		return n, err
+	}
+	c.read = c.record(transcriptServerPrefix, append(c.read, b[:n]...))
+	return n, err
+}
+
+func (c *transcriptConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if c.encrypted {
+		return n, err
+	}
+	c.written = c.record(transcriptClientPrefix, append(c.written, b[:n]...))
+	return n, err
+}
+
+// record adds the complete lines of data to the transcript and returns the remaining partial line
+func (c *transcriptConn) record(prefix string, data []byte) []byte {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return data
+		}
+		line := string(bytes.TrimRight(data[:i], "\r"))
+		c.t.add(c.host, prefix+line)
+		if prefix == transcriptClientPrefix {
+			c.verb, _, _ = strings.Cut(line, " ")
+		} else {
+			c.noteGreeting(line)
+			if c.verb == "STARTTLS" && strings.HasPrefix(line, "220 ") {
+				// the bytes which follow are the TLS handshake and records
+				c.encrypted = true
+				c.t.add(c.host, transcriptNotePrefix+"TLS started, the encrypted conversation isn't recorded")
+				return nil
+			}
+		}
+		data = data[i+1:]
+	}
+}
+
+// noteGreeting notes which greeting the server accepted, as EHLO falls back to HELO
+func (c *transcriptConn) noteGreeting(reply string) {
+	if c.verb != "EHLO" && c.verb != "HELO" {
+		return
+	}
+	// the last line of a reply has a space after the code
+	if len(reply) < 4 || reply[3] != ' ' || reply[0] != '2' {
+		return
+	}
+	c.t.add(c.host, transcriptNotePrefix+"greeting: "+c.verb)
+}
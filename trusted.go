@@ -0,0 +1,28 @@
+package emailverifier
+
+import "strings"
+
+// defaultTrustedVerdict is the SMTP result of the trusted domains unless set by TrustedDomainVerdict
+var defaultTrustedVerdict = SMTP{HostExists: true, Deliverable: true, CatchAllStatus: CatchAllUnknown}
+
+// isTrustedDomain checks if domain or one of its parent domains was trusted by TrustDomains
+func (v *Verifier) isTrustedDomain(domain string) bool {
+	if len(v.trustedDomains) == 0 {
+		return false
+	}
+	domain = cleanDomain(domain)
+	for ; strings.Contains(domain, "."); domain = parentDomain(domain) {
+		if v.trustedDomains[domain] {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedSMTP returns the SMTP result reported for a trusted domain without connecting to it
+func (v *Verifier) trustedSMTP(domain string) *SMTP {
+	v.logger.Debug("trusted domain, skipping the SMTP check", "domain", domain)
+	ret := v.trustedVerdict
+	ret.Trusted = true
+	return &ret
+}
@@ -0,0 +1,56 @@
+package emailverifier
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_TrustedDomains(t *testing.T) {
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+	dials := countDials()
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	v := NewVerifier().EnableSMTPCheck().TrustDomains("Corp.Example.")
+	for _, email := range []string{"info@corp.example", "anyone@eu.corp.example"} {
+		ret, err := v.Verify(email)
+		assert.NoError(t, err)
+		assert.Equal(t, &SMTP{HostExists: true, Deliverable: true, CatchAllStatus: CatchAllUnknown, Trusted: true}, ret.SMTP, email)
+		assert.Equal(t, reachableYes, ret.Reachable, email)
+		assert.True(t, ret.Syntax.Valid)
+		assert.True(t, ret.HasMxRecords)
+	}
+	assert.True(t, resultOf(v.Verify("info@corp.example")).RoleAccount)
+	assert.Zero(t, *dials)
+
+	// strict mode doesn't second-guess a trusted domain
+	v.EnableStrictMode()
+	assert.Equal(t, reachableYes, resultOf(v.Verify("user@corp.example")).Reachable)
+	v.DisableStrictMode()
+
+	v.TrustedDomainVerdict(SMTP{HostExists: true, CatchAllStatus: CatchAllYes, CatchAll: true})
+	assert.Equal(t, reachableUnknown, resultOf(v.Verify("user@corp.example")).Reachable)
+	assert.Zero(t, *dials)
+
+	// untrusted domains are checked by SMTP
+	ret, err := v.Verify("user@corp.example.net")
+	assert.NoError(t, err)
+	assert.False(t, ret.SMTP.Trusted)
+	assert.EqualValues(t, 1, *dials)
+
+	v.UntrustDomains("corp.example")
+	_, err = v.CheckSMTP("corp.example", "user")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, *dials)
+}
+
+// resultOf returns the result of Verify, ignoring its error
+func resultOf(r *Result, _ error) *Result {
+	return r
+}
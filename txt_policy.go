@@ -0,0 +1,118 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TXTPolicy is a policy published in the TXT records of a name, e.g. SPF or DMARC,
+// with the records exactly as served by DNS
+type TXTPolicy struct {
+	Name     string   `json:"name"`               // name queried, e.g. "_dmarc.example.com"
+	Record   string   `json:"record,omitempty"`   // the policy record, empty when there is none or several
+	Records  []string `json:"records,omitempty"`  // every TXT record of Name starting with the version tag of the policy
+	Multiple bool     `json:"multiple,omitempty"` // several policy records are published, which breaks the evaluation of the policy
+	TXT      []string `json:"txt,omitempty"`      // every TXT record of Name, only recorded with EnableRawTXTRecords
+}
+
+// CheckSPF queries the SPF record of domain, the TXT record starting with "v=spf1".
+// A domain publishing several SPF records has TXTPolicy.Multiple set and no Record,
+// as SPF evaluates to a permerror (RFC 7208 section 4.5). A domain without SPF record
+// has none, errors are only returned when DNS can't be queried.
+func (v *Verifier) CheckSPF(domain string) (*TXTPolicy, error) {
+	v = v.snapshot()
+	domain = cleanDomain(domain)
+	if domain == "" {
+		return nil, errors.New("empty domain")
+	}
+	return v.checkTXTPolicy(context.Background(), domain, "v=spf1")
+}
+
+// CheckDMARC queries the DMARC record of domain, the TXT record of "_dmarc.<domain>"
+// starting with "v=DMARC1". A domain publishing several DMARC records has TXTPolicy.Multiple
+// set and no Record, as DMARC isn't applied (RFC 7489 section 6.6.3). See CheckSPF for errors.
+func (v *Verifier) CheckDMARC(domain string) (*TXTPolicy, error) {
+	v = v.snapshot()
+	domain = cleanDomain(domain)
+	if domain == "" {
+		return nil, errors.New("empty domain")
+	}
+	return v.checkTXTPolicy(context.Background(), "_dmarc."+domain, "v=DMARC1")
+}
+
+// EnableRawTXTRecords records every TXT record of the names queried by CheckSPF,
+// CheckDMARC and SelfCheck in TXTPolicy.TXT, e.g. for compliance reports showing
+// the verbatim DNS data
+func (v *Verifier) EnableRawTXTRecords() *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rawTXTEnabled = true
+	return v
+}
+
+// DisableRawTXTRecords only records the policy records in TXTPolicy
+func (v *Verifier) DisableRawTXTRecords() *Verifier {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rawTXTEnabled = false
+	return v
+}
+
+// checkTXTPolicy looks up the TXT records of name starting with the version tag prefix
+func (v *Verifier) checkTXTPolicy(ctx context.Context, name, prefix string) (*TXTPolicy, error) {
+	txt, err := lookupTXTRecords(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	ret := &TXTPolicy{Name: name, Records: recordsWithVersion(txt, prefix)}
+	if v.rawTXTEnabled {
+		ret.TXT = txt
+	}
+	switch len(ret.Records) {
+	case 0:
+	case 1:
+		ret.Record = ret.Records[0]
+	default:
+		ret.Multiple = true
+	}
+	return ret, nil
+}
+
+// lookupTXTRecords returns the TXT records of name as served, a name without TXT records has none
+func lookupTXTRecords(ctx context.Context, name string) ([]string, error) {
+	records, err := lookupTXTContext(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query TXT %s: %w", name, err)
+	}
+	return records, nil
+}
+
+// recordsWithVersion returns the records starting with the version tag prefix
+// (e.g. "v=spf1"), compared case-insensitively
+func recordsWithVersion(records []string, prefix string) []string {
+	var ret []string
+	for _, record := range records {
+		version, _, _ := strings.Cut(record, " ")
+		version, _, _ = strings.Cut(version, ";")
+		if strings.EqualFold(version, prefix) {
+			ret = append(ret, record)
+		}
+	}
+	return ret
+}
+
+// dmarcPolicy returns the lowercased p= tag of the DMARC record, empty when it has none
+func dmarcPolicy(record string) string {
+	for _, tag := range strings.Split(record, ";") {
+		name, value, found := strings.Cut(tag, "=")
+		if found && strings.EqualFold(strings.TrimSpace(name), "p") {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSPF(t *testing.T) {
+	defer stubLookupTXT(map[string][]string{
+		"example.com":  {"google-site-verification=abc", "v=spf1 include:_spf.google.com ~all"},
+		"multiple.com": {"v=spf1 include:_spf.google.com ~all", "V=SPF1 mx -all", "v=spf10 -all"},
+	})()
+
+	spf, err := NewVerifier().CheckSPF("Example.com.")
+	assert.NoError(t, err)
+	assert.Equal(t, &TXTPolicy{
+		Name:    "example.com",
+		Record:  "v=spf1 include:_spf.google.com ~all",
+		Records: []string{"v=spf1 include:_spf.google.com ~all"},
+	}, spf)
+
+	spf, err = NewVerifier().EnableRawTXTRecords().CheckSPF("multiple.com")
+	assert.NoError(t, err)
+	assert.True(t, spf.Multiple)
+	assert.Empty(t, spf.Record)
+	assert.Equal(t, []string{"v=spf1 include:_spf.google.com ~all", "V=SPF1 mx -all"}, spf.Records)
+	assert.Equal(t, []string{"v=spf1 include:_spf.google.com ~all", "V=SPF1 mx -all", "v=spf10 -all"}, spf.TXT)
+
+	spf, err = NewVerifier().CheckSPF("none.com")
+	assert.NoError(t, err)
+	assert.Equal(t, &TXTPolicy{Name: "none.com"}, spf)
+
+	_, err = NewVerifier().CheckSPF(" ")
+	assert.Error(t, err)
+}
+
+func TestCheckDMARC(t *testing.T) {
+	defer stubLookupTXT(map[string][]string{
+		"_dmarc.example.com":  {"v=DMARC1; p=reject"},
+		"_dmarc.multiple.com": {"v=DMARC1; p=reject", "v=DMARC1; p=none"},
+	})()
+
+	v := NewVerifier().EnableRawTXTRecords()
+	dmarc, err := v.CheckDMARC("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "_dmarc.example.com", dmarc.Name)
+	assert.Equal(t, "v=DMARC1; p=reject", dmarc.Record)
+	assert.Equal(t, []string{"v=DMARC1; p=reject"}, dmarc.TXT)
+
+	dmarc, err = v.CheckDMARC("multiple.com")
+	assert.NoError(t, err)
+	assert.True(t, dmarc.Multiple)
+	assert.Empty(t, dmarc.Record)
+	assert.Len(t, dmarc.Records, 2)
+
+	dmarc, err = v.DisableRawTXTRecords().CheckDMARC("example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, dmarc.TXT)
+}
+
+func TestCheckSPF_DNSError(t *testing.T) {
+	original := lookupTXTContext
+	defer func() { lookupTXTContext = original }()
+	lookupTXTContext = func(ctx context.Context, name string) ([]string, error) {
+		return nil, errors.New("i/o timeout")
+	}
+
+	_, err := NewVerifier().CheckSPF("example.com")
+	assert.ErrorContains(t, err, "query TXT example.com")
+}
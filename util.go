@@ -1,8 +1,10 @@
 package emailverifier
 
 import (
+	"bufio"
 	"crypto/md5" //nolint:gosec
 	"encoding/hex"
+	"io"
 	"reflect"
 	"strings"
 
@@ -19,6 +21,32 @@ func splitDomain(domain string) (string, string) {
 	return "", parts[0]
 }
 
+// stripPlusAddressing removes the sub-address suffix from a local part,
+// e.g. "john+newsletter" becomes "john"
+func stripPlusAddressing(username string) string {
+	if i := strings.Index(username, "+"); i > 0 {
+		return username[:i]
+	}
+	return username
+}
+
+// cleanDomain normalizes a domain for lookups in domain lists:
+// surrounding spaces and the trailing dot are removed, the domain is lowercased
+// and converted to ASCII
+func cleanDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	return domainToASCII(domain)
+}
+
+// cleanDomains normalizes every domain with cleanDomain
+func cleanDomains(domains []string) []string {
+	ret := make([]string, 0, len(domains))
+	for _, d := range domains {
+		ret = append(ret, cleanDomain(d))
+	}
+	return ret
+}
+
 // domainToASCII converts any internationalized domain names to ASCII
 // reference: https://en.wikipedia.org/wiki/Punycode
 func domainToASCII(domain string) string {
@@ -57,3 +85,23 @@ func getMD5Hash(str string) (error, string) {
 	}
 	return nil, hex.EncodeToString(h.Sum(nil))
 }
+
+// readList reads the newline-delimited entries of r normalized by normalize,
+// blank lines and comment lines starting with "#" are skipped
+func readList(r io.Reader, normalize func(string) string) (map[string]struct{}, error) {
+	entries := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if entry := normalize(line); entry != "" {
+			entries[entry] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -71,3 +71,10 @@ func TestSplitDomainSubDomain(t *testing.T) {
 	assert.Equal(t, "aftership", sld)
 	assert.Equal(t, "com", tld)
 }
+
+func TestStripPlusAddressing(t *testing.T) {
+	assert.Equal(t, "john", stripPlusAddressing("john+tag"))
+	assert.Equal(t, "john", stripPlusAddressing("john+tag+more"))
+	assert.Equal(t, "john", stripPlusAddressing("john"))
+	assert.Equal(t, "+tag", stripPlusAddressing("+tag"))
+}
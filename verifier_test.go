@@ -1,9 +1,19 @@
 package emailverifier
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
 )
 
 func TestCheckEmailOK_SMTPHostNotExists(t *testing.T) {
@@ -29,6 +39,13 @@ func TestCheckEmailOK_SMTPHostNotExists(t *testing.T) {
 		Reachable:    reachableUnknown,
 		Free:         false,
 		SMTP:         nil,
+		ChecksPerformed: ChecksPerformed{
+			SyntaxChecked:      true,
+			DisposableChecked:  true,
+			RoleAccountChecked: true,
+			FreeChecked:        true,
+			MXChecked:          true,
+		},
 	}
 	assert.Error(t, err, ErrNoSuchHost)
 	assert.Equal(t, &expected, ret)
@@ -57,11 +74,12 @@ func TestCheckEmailOK_SMTPHostExists_NotCatchAll(t *testing.T) {
 		RoleAccount:  false,
 		Free:         false,
 		SMTP: &SMTP{
-			HostExists:  true,
-			FullInbox:   false,
-			CatchAll:    true,
-			Deliverable: false,
-			Disabled:    false,
+			HostExists:     true,
+			FullInbox:      false,
+			CatchAll:       true,
+			CatchAllStatus: CatchAllYes,
+			Deliverable:    false,
+			Disabled:       false,
 		},
 	}
 	assert.Nil(t, err)
@@ -91,11 +109,12 @@ func TestCheckEmailOK_SMTPHostExists_FreeDomain(t *testing.T) {
 		RoleAccount:  false,
 		Free:         true,
 		SMTP: &SMTP{
-			HostExists:  true,
-			FullInbox:   false,
-			CatchAll:    false,
-			Deliverable: false,
-			Disabled:    false,
+			HostExists:     true,
+			FullInbox:      false,
+			CatchAll:       false,
+			CatchAllStatus: CatchAllNo,
+			Deliverable:    false,
+			Disabled:       false,
 		},
 	}
 	assert.Nil(t, err)
@@ -118,13 +137,17 @@ func TestCheckEmail_ErrorSyntax(t *testing.T) {
 			Username: username,
 			Domain:   "",
 			Valid:    false,
+			Reason:   SyntaxReasonLocalPartEmpty,
 		},
 		HasMxRecords: false,
-		Reachable:    reachableUnknown,
+		Reachable:    reachableNo,
 		Disposable:   false,
 		RoleAccount:  false,
 		Free:         false,
 		SMTP:         nil,
+		ChecksPerformed: ChecksPerformed{
+			SyntaxChecked: true,
+		},
 	}
 	assert.Nil(t, err)
 	assert.Equal(t, &expected, ret)
@@ -147,17 +170,68 @@ func TestCheckEmail_Disposable(t *testing.T) {
 			Domain:   domain,
 			Valid:    true,
 		},
-		HasMxRecords: false,
-		Reachable:    reachableUnknown,
-		Disposable:   true,
-		RoleAccount:  false,
-		Free:         false,
-		SMTP:         nil,
+		HasMxRecords:     false,
+		Reachable:        reachableUnknown,
+		Disposable:       true,
+		DisposableSource: DisposableSourceList,
+		RoleAccount:      false,
+		Free:             false,
+		SMTP:             nil,
+		ChecksPerformed: ChecksPerformed{
+			SyntaxChecked:      true,
+			DisposableChecked:  true,
+			RoleAccountChecked: true,
+			FreeChecked:        true,
+		},
 	}
 	assert.Nil(t, err)
 	assert.Equal(t, &expected, ret)
 }
 
+func TestCheckEmail_Normalized(t *testing.T) {
+	email := " Example User <ExampleUser@ZZJBFWQI.shop> "
+
+	ret, err := verifier.Verify(email)
+	expected := Result{
+		Email:           email,
+		NormalizedEmail: "ExampleUser@zzjbfwqi.shop",
+		Syntax: Syntax{
+			Username: "ExampleUser",
+			Domain:   "zzjbfwqi.shop",
+			Valid:    true,
+		},
+		Reachable:        reachableUnknown,
+		Disposable:       true,
+		DisposableSource: DisposableSourceList,
+		ChecksPerformed: ChecksPerformed{
+			SyntaxChecked:      true,
+			DisposableChecked:  true,
+			RoleAccountChecked: true,
+			FreeChecked:        true,
+		},
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, &expected, ret)
+
+	// invalid characters still fail the syntax check
+	ret, err = verifier.Verify(" john doe@example.com ")
+	assert.Nil(t, err)
+	assert.False(t, ret.Syntax.Valid)
+	assert.Equal(t, SyntaxReasonInvalidCharacter, ret.Syntax.Reason)
+}
+
+func TestCheckEmail_CanonicalDomain(t *testing.T) {
+	v := NewVerifier().MapDomainAlias("zzjbfwqi.shop", "primary.test")
+	ret, err := v.Verify("exampleuser@ZZJBFWQI.shop")
+	assert.Nil(t, err)
+	assert.Equal(t, "zzjbfwqi.shop", ret.Syntax.Domain)
+	assert.Equal(t, "primary.test", ret.CanonicalDomain)
+
+	ret, err = verifier.Verify("exampleuser@zzjbfwqi.shop")
+	assert.Nil(t, err)
+	assert.Equal(t, "", ret.CanonicalDomain)
+}
+
 func TestCheckEmail_Disposable_override(t *testing.T) {
 	var (
 		username = "exampleuser"
@@ -166,7 +240,7 @@ func TestCheckEmail_Disposable_override(t *testing.T) {
 		email    = address
 	)
 
-	verifier := NewVerifier().EnableSMTPCheck().AddDisposableDomains([]string{"iamdisposableemail.test"})
+	verifier := NewVerifier().EnableSMTPCheck().AddDisposableDomains("iamdisposableemail.test")
 	ret, err := verifier.Verify(email)
 	expected := Result{
 		Email: email,
@@ -175,12 +249,19 @@ func TestCheckEmail_Disposable_override(t *testing.T) {
 			Domain:   domain,
 			Valid:    true,
 		},
-		HasMxRecords: false,
-		Reachable:    reachableUnknown,
-		Disposable:   true,
-		RoleAccount:  false,
-		Free:         false,
-		SMTP:         nil,
+		HasMxRecords:     false,
+		Reachable:        reachableUnknown,
+		Disposable:       true,
+		DisposableSource: DisposableSourceList,
+		RoleAccount:      false,
+		Free:             false,
+		SMTP:             nil,
+		ChecksPerformed: ChecksPerformed{
+			SyntaxChecked:      true,
+			DisposableChecked:  true,
+			RoleAccountChecked: true,
+			FreeChecked:        true,
+		},
 	}
 	assert.Nil(t, err)
 	assert.Equal(t, &expected, ret)
@@ -209,11 +290,12 @@ func TestCheckEmail_RoleAccount(t *testing.T) {
 		RoleAccount:  true,
 		Free:         false,
 		SMTP: &SMTP{
-			HostExists:  true,
-			FullInbox:   false,
-			CatchAll:    true,
-			Deliverable: false,
-			Disabled:    false,
+			HostExists:     true,
+			FullInbox:      false,
+			CatchAll:       true,
+			CatchAllStatus: CatchAllYes,
+			Deliverable:    false,
+			Disabled:       false,
 		},
 	}
 	assert.Nil(t, err)
@@ -258,6 +340,34 @@ func TestNewVerifierOK_EnableAutoUpdateDisposable(t *testing.T) {
 	verifier.EnableAutoUpdateDisposable()
 }
 
+func TestEnableAutoUpdateDisposable_DoesNotBlockChecks(t *testing.T) {
+	fetching, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(fetching)
+		<-release
+		// the fetch fails, the disposable domains of the other tests are kept
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer useFakeMX()()
+
+	v := NewVerifier().DisposableDataURL(server.URL)
+	defer v.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v.EnableAutoUpdateDisposable()
+	}()
+	<-fetching
+
+	// the settings and the checks don't wait for the first fetch
+	v.EnableSMTPCheck().DisableSMTPCheck()
+	_, err := v.Verify("user@example.com")
+	assert.NoError(t, err)
+	close(release)
+	<-done
+}
+
 func TestNewVerifierOK_AutoUpdateDisposableDuplicate(t *testing.T) {
 	verifier.DisableAutoUpdateDisposable()
 
@@ -292,3 +402,397 @@ func TestCheckEmail_EnableDomainSuggest(t *testing.T) {
 
 	assert.Empty(t, ret.Suggestion)
 }
+
+func TestEnableAutoUpdateDisposable_CustomDataURL(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://mirror.example.internal").
+		Get("/disposable.json").
+		Reply(http.StatusOK).
+		JSON([]string{"mirror-disposable.test", "zzjbfwqi.shop", "dbbd8.club"})
+
+	v := NewVerifier().
+		DisposableDataURL("https://mirror.example.internal/disposable.json").
+		DisposableUpdateInterval(time.Hour).
+		EnableAutoUpdateDisposable()
+	defer v.Close()
+
+	assert.True(t, v.IsDisposable("mirror-disposable.test"))
+}
+
+func TestEnableAutoUpdateDisposable_FailedFetchKeepsList(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://mirror.example.internal").
+		Get("/disposable.json").
+		Reply(http.StatusInternalServerError)
+
+	v := NewVerifier().
+		AddDisposableDomains("kept-disposable.test").
+		DisposableDataURL("https://mirror.example.internal/disposable.json").
+		EnableAutoUpdateDisposable()
+
+	assert.True(t, v.IsDisposable("kept-disposable.test"))
+	assert.True(t, v.IsDisposable("zzjbfwqi.shop"))
+	assert.NoError(t, v.Close())
+}
+
+func TestCheckEmail_DisableFreeCheck(t *testing.T) {
+	// a free domain marked as disposable skips all network checks
+	domain := "126.com"
+	v := NewVerifier().AddDisposableDomains(domain)
+	defer v.RemoveDisposableDomains(domain)
+
+	ret, err := v.Verify("user@" + domain)
+	assert.NoError(t, err)
+	assert.True(t, ret.Free)
+
+	ret, err = v.DisableFreeCheck().Verify("user@" + domain)
+	assert.NoError(t, err)
+	assert.False(t, ret.Free)
+}
+
+func TestVerifyContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ret, err := NewVerifier().EnableSMTPCheck().VerifyContext(ctx, "email_username@github.com")
+	assert.Error(t, err)
+	assert.True(t, ret.Syntax.Valid)
+	assert.Nil(t, ret.SMTP)
+	assert.Equal(t, reachableUnknown, ret.Reachable)
+}
+
+func TestVerify_GravatarFilledWhenMXFails(t *testing.T) {
+	email := "email_username@domainnotexists.com"
+	defer gock.Off()
+	gock.New("https://www.gravatar.com").
+		Get("/avatar/").
+		Reply(http.StatusOK).
+		BodyString("avatar")
+
+	ret, err := NewVerifier().EnableGravatarCheck().Verify(email)
+	assert.Error(t, err)
+	if assert.NotNil(t, ret.Gravatar) {
+		assert.True(t, ret.Gravatar.HasGravatar)
+	}
+	assert.False(t, ret.HasMxRecords)
+}
+
+func TestCalculateReachable(t *testing.T) {
+	valid := Syntax{Username: "user", Domain: "example.com", Valid: true}
+	cases := []struct {
+		name     string
+		syntax   Syntax
+		smtp     *SMTP
+		expected string
+	}{
+		{name: "invalid syntax", syntax: Syntax{}, smtp: nil, expected: reachableNo},
+		{name: "smtp not performed", syntax: valid, smtp: nil, expected: reachableUnknown},
+		{name: "host not exists", syntax: valid, smtp: &SMTP{}, expected: reachableUnknown},
+		{name: "deliverable", syntax: valid, smtp: &SMTP{HostExists: true, Deliverable: true}, expected: reachableYes},
+		{name: "catch-all", syntax: valid, smtp: &SMTP{HostExists: true, CatchAll: true}, expected: reachableUnknown},
+		{name: "mailbox not found", syntax: valid, smtp: &SMTP{HostExists: true}, expected: reachableNo},
+		{name: "full inbox", syntax: valid, smtp: &SMTP{HostExists: true, FullInbox: true}, expected: reachableNo},
+	}
+	v := NewVerifier().EnableSMTPCheck()
+	for _, c := range cases {
+		test := c
+		t.Run(test.name, func(tt *testing.T) {
+			assert.Equal(tt, test.expected, v.calculateReachable(test.syntax, test.smtp))
+		})
+	}
+}
+
+func TestMapDomainToAPIVerifier(t *testing.T) {
+	v := NewVerifier().EnableSMTPCheck()
+
+	err := v.MapDomainToAPIVerifier("example.org", "unknown")
+	assert.EqualError(t, err, v.EnableAPIVerifier("unknown").Error())
+
+	defer gock.Off()
+	gock.New("https://mail.google.com").
+		Get("/mail/gxlu").
+		MatchParam("email", "someone@example.org").
+		Reply(http.StatusNoContent).
+		SetHeader("Set-Cookie", "COMPASS=gmail=abc; Path=/mail")
+
+	assert.NoError(t, v.MapDomainToAPIVerifier("Example.org.", GMAIL))
+	smtp, err := v.CheckSMTP("example.org", "someone")
+	assert.NoError(t, err)
+	assert.Equal(t, &SMTP{HostExists: true, CatchAllStatus: CatchAllUnknown, Deliverable: true}, smtp)
+}
+
+func TestVerify_PlusAddressNormalization(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+
+	v := NewVerifier().EnableSMTPCheck().EnablePlusAddressNormalization()
+
+	ret, err := v.Verify("user+news@gmail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "user+news", ret.Syntax.Username)
+	assert.Equal(t, "user@gmail.com", ret.VerifiedEmail)
+	assert.True(t, ret.SMTP.Deliverable)
+
+	// plus-addressing isn't known to be supported, the literal address is checked
+	ret, err = v.Verify("user+news@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.VerifiedEmail)
+	assert.False(t, ret.SMTP.Deliverable)
+
+	ret, err = v.DisablePlusAddressNormalization().Verify("user+news@gmail.com")
+	assert.NoError(t, err)
+	assert.Empty(t, ret.VerifiedEmail)
+	assert.False(t, ret.SMTP.Deliverable)
+}
+
+func TestVerify_StrictMode(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	strict := NewVerifier().EnableSMTPCheck().EnableStrictMode()
+	lenient := NewVerifier().EnableSMTPCheck()
+
+	t.Run("deliverable", func(t *testing.T) {
+		defer useFakeSMTPServer(t, rejectRandomRcpt)()
+		ret, err := strict.Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, reachableYes, ret.Reachable)
+	})
+
+	t.Run("catch-all", func(t *testing.T) {
+		defer useFakeSMTPServer(t, func(string) string { return "" })()
+		ret, err := lenient.Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, reachableUnknown, ret.Reachable)
+
+		ret, err = strict.Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, reachableNo, ret.Reachable)
+	})
+
+	t.Run("greylisted", func(t *testing.T) {
+		defer useFakeSMTPServer(t, func(cmd string) string {
+			if strings.HasPrefix(cmd, "RCPT") {
+				return "450 4.7.1 Greylisted, try again later"
+			}
+			return ""
+		})()
+		ret, err := lenient.Verify("user@example.com")
+		assert.Error(t, err)
+		assert.Equal(t, reachableUnknown, ret.Reachable)
+
+		ret, err = strict.Verify("user@example.com")
+		assert.Error(t, err)
+		assert.Equal(t, reachableNo, ret.Reachable)
+	})
+
+	t.Run("mx only", func(t *testing.T) {
+		defer useFakeSMTPServer(t, rejectRandomRcpt)()
+		ret, err := NewVerifier().EnableSMTPCheck().EnableStrictMode().EnableMXOnlyMode().Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, reachableNo, ret.Reachable)
+	})
+
+	// the backends other than SMTP don't probe for a catch-all address
+	t.Run("mailbox checker", func(t *testing.T) {
+		checker := MailboxCheckerFunc(func(ctx context.Context, domain, username string) (*SMTP, error) {
+			return &SMTP{HostExists: true, Deliverable: true}, nil
+		})
+		ret, err := NewVerifier().EnableSMTPCheck().EnableStrictMode().RegisterMailboxChecker(checker, "example.com").Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, CatchAllUnknown, ret.SMTP.CatchAllStatus)
+		assert.Equal(t, reachableYes, ret.Reachable)
+	})
+
+	t.Run("api verifier", func(t *testing.T) {
+		v := NewVerifier().EnableSMTPCheck().EnableStrictMode()
+		v.apiDomains = map[string]smtpAPIVerifier{"example.com": stubAPIVerifier{&SMTP{HostExists: true, Deliverable: true}}}
+		ret, err := v.Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, MailboxBackendAPI, ret.ChecksPerformed.MailboxBackend)
+		assert.Equal(t, reachableYes, ret.Reachable)
+	})
+
+	t.Run("relay", func(t *testing.T) {
+		cert := selfSignedCert(t, "relay.example.net", time.Now().Add(time.Hour))
+		relay, _ := fakeSubmissionRelay(cert, "PLAIN", "verifier", "s3cret-pass")
+		_, restore := useFakeSubmissionRelay(t, cert, relay)
+		defer restore()
+		ret, err := NewVerifier().EnableSMTPCheck().EnableStrictMode().SubmissionRelay("relay.example.net", "verifier", "s3cret-pass").Verify("user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, MailboxBackendRelay, ret.ChecksPerformed.MailboxBackend)
+		assert.Equal(t, reachableYes, ret.Reachable)
+	})
+}
+
+func TestAPIVerifierFor_BoundByContext(t *testing.T) {
+	original := lookupMXContext
+	defer func() { lookupMXContext = original }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	v := NewVerifier()
+	v.apiVerifiers = map[string]smtpAPIVerifier{"stub": stubAPIVerifier{&SMTP{Deliverable: true}}}
+
+	assert.NotNil(t, v.apiVerifierFor(context.Background(), "example.com"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Nil(t, v.apiVerifierFor(ctx, "example.com"))
+}
+
+// stubAPIVerifier is an API verifier of every host answering ret
+type stubAPIVerifier struct {
+	ret *SMTP
+}
+
+func (s stubAPIVerifier) isSupported(host string) bool {
+	return true
+}
+
+func (s stubAPIVerifier) check(ctx context.Context, domain, username string, opts apiOptions) (*SMTP, error) {
+	ret := *s.ret
+	return &ret, nil
+}
+
+func TestVerifier_ConcurrentSettings(t *testing.T) {
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+	defer useFakeSMTPServer(t, rejectRandomRcpt)()
+
+	v := NewVerifier().EnableSMTPCheck()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := v.Verify("user@example.com")
+			assert.NoError(t, err)
+			_, err = v.CheckSMTP("example.com", "user")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			v.FromEmail("user@example.org").HelloName("mta.example.org").CatchAllProbeCount(2)
+			v.MapDomainAlias("mail.example.com", "example.com")
+			assert.NoError(t, v.EnableAPIVerifier(GMAIL))
+			v.DisableAPIVerifier(GMAIL)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestVerifyOffline_ConcurrentSettings(t *testing.T) {
+	v := NewVerifier()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			v.EnableSMTPCheck().DisableSMTPCheck()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, reachableUnknown, v.VerifyOffline("user@example.com").Reachable)
+	}
+	<-done
+}
+
+func TestVerify_InvalidSyntaxSkipsNetwork(t *testing.T) {
+	originalLookupMX, originalLookupHost, originalDialSMTP := lookupMXContext, lookupHostContext, dialSMTPFunc
+	defer func() {
+		lookupMXContext, lookupHostContext, dialSMTPFunc = originalLookupMX, originalLookupHost, originalDialSMTP
+	}()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		t.Errorf("unexpected MX lookup of %s", domain)
+		return nil, errors.New("unexpected MX lookup")
+	}
+	lookupHostContext = func(ctx context.Context, host string) ([]string, error) {
+		t.Errorf("unexpected lookup of %s", host)
+		return nil, errors.New("unexpected lookup")
+	}
+	dialSMTPFunc = func(addr string, opts dialOptions) (*smtp.Client, error) {
+		t.Errorf("unexpected dial of %s", addr)
+		return nil, errors.New("unexpected dial")
+	}
+
+	v := NewVerifier().EnableSMTPCheck().EnableGravatarCheck().EnableDomainAgeCheck().
+		EnableDisposableMXHeuristic().EnableDomainSuggest()
+	cases := map[string]string{
+		"":               SyntaxReasonEmpty,
+		"no-at-sign":     SyntaxReasonMissingAt,
+		"user@":          SyntaxReasonInvalidDomain,
+		"user..x@ex.com": SyntaxReasonConsecutiveDots,
+	}
+	for email, reason := range cases {
+		ret, err := v.Verify(email)
+		assert.NoError(t, err, email)
+		assert.Equal(t, reachableNo, ret.Reachable, email)
+		assert.False(t, ret.Syntax.Valid, email)
+		assert.Equal(t, reason, ret.Syntax.Reason, email)
+		assert.Nil(t, ret.SMTP, email)
+		assert.Nil(t, ret.Gravatar, email)
+	}
+}
+
+func TestVerifyOffline(t *testing.T) {
+	v := NewVerifier().EnableSMTPCheck().EnableDomainSuggest()
+	originalLookupMX := lookupMXContext
+	defer func() { lookupMXContext = originalLookupMX }()
+	lookupMXContext = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		t.Fatal("VerifyOffline looked up the MX records")
+		return nil, nil
+	}
+
+	ret := v.VerifyOffline(" Support@GMAIL.com ")
+	assert.Equal(t, &Result{
+		Email:           " Support@GMAIL.com ",
+		NormalizedEmail: "Support@gmail.com",
+		Reachable:       reachableUnknown,
+		Syntax:          Syntax{Username: "Support", Domain: "gmail.com", Valid: true},
+		Free:            true,
+		RoleAccount:     true,
+		RoleName:        "support",
+		ChecksPerformed: ChecksPerformed{
+			SyntaxChecked:      true,
+			DisposableChecked:  true,
+			RoleAccountChecked: true,
+			FreeChecked:        true,
+			SuggestionChecked:  true,
+		},
+	}, ret)
+
+	ret = v.VerifyOffline("support+ticket123@example.com")
+	assert.True(t, ret.RoleAccount)
+	assert.Equal(t, "support", ret.RoleName)
+
+	ret = v.VerifyOffline("user@zzjbfwqi.shop")
+	assert.True(t, ret.Disposable)
+	assert.Equal(t, DisposableSourceList, ret.DisposableSource)
+	assert.Nil(t, ret.SMTP)
+
+	assert.Equal(t, "gmail.com", v.VerifyOffline("user@gmaill.com").Suggestion)
+
+	ret = v.VerifyOffline("not an email")
+	assert.False(t, ret.Syntax.Valid)
+	assert.Equal(t, reachableNo, ret.Reachable)
+}
+
+func BenchmarkVerifyOffline(b *testing.B) {
+	v := NewVerifier()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.VerifyOffline("support@gmail.com")
+	}
+}
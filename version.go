@@ -0,0 +1,34 @@
+package emailverifier
+
+import "runtime/debug"
+
+// modulePath is the path of the module, looked up in the build information
+const modulePath = "github.com/AfterShip/email-verifier"
+
+// Version is the version of the module, sent in DefaultAPIUserAgent. It is read from
+// the build information of the binary, e.g. "v1.5.0" when the module is a dependency
+// required at that version, so it needs no bump on release. It is "devel" when the
+// version is unknown, e.g. in the tests of the module or with a replace directive
+// pointing to a directory.
+var Version = moduleVersion(debug.ReadBuildInfo())
+
+// moduleVersion returns the version of the module in info, "devel" when ok is false
+// or the module has no version
+func moduleVersion(info *debug.BuildInfo, ok bool) string {
+	if !ok {
+		return "devel"
+	}
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	for _, m := range modules {
+		if m.Path != modulePath {
+			continue
+		}
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		if m.Version != "" && m.Version != "(devel)" {
+			return m.Version
+		}
+	}
+	return "devel"
+}
@@ -0,0 +1,31 @@
+package emailverifier
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleVersion(t *testing.T) {
+	dependency := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/net", Version: "v0.30.0"},
+			{Path: modulePath, Version: "v1.6.0"},
+		},
+	}
+	assert.Equal(t, "v1.6.0", moduleVersion(dependency, true))
+
+	// a replacement by another version is reported, one by a directory isn't
+	dependency.Deps[1].Replace = &debug.Module{Path: "example.com/fork", Version: "v1.6.1"}
+	assert.Equal(t, "v1.6.1", moduleVersion(dependency, true))
+	dependency.Deps[1].Replace = &debug.Module{Path: "../email-verifier"}
+	assert.Equal(t, "devel", moduleVersion(dependency, true))
+
+	main := &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"}}
+	assert.Equal(t, "devel", moduleVersion(main, true))
+	assert.Equal(t, "devel", moduleVersion(nil, false))
+
+	assert.Equal(t, "email-verifier/"+Version, DefaultAPIUserAgent)
+}
@@ -0,0 +1,205 @@
+package emailverifier
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	whoisPort       = ":43"
+	whoisIANAServer = "whois.iana.org" // tells the WHOIS server of every top level domain
+)
+
+var whoisQueryFunc = queryWhois
+
+// whoisServers caches the WHOIS server of top level domains resolved through IANA
+var whoisServers sync.Map
+
+// whoisCreationKeys are the keys of the creation date in the WHOIS responses of common registries
+// and registrars, in lower case
+var whoisCreationKeys = []string{
+	"creation date",
+	"created",
+	"created on",
+	"created date",
+	"creation time",
+	"registered",
+	"registered on",
+	"registration date",
+	"registration time",
+	"domain registration date",
+	"domain create date",
+	"domain record activated",
+}
+
+// whoisDateLayouts are the date formats of the creation date in WHOIS responses
+var whoisDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"02-Jan-2006",
+	"02-January-2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+	"January 2 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+	"20060102",
+}
+
+// DomainAge is the registration detail of a domain found by WHOIS
+type DomainAge struct {
+	Domain      string        `json:"domain"`       // registered domain queried, e.g. "example.co.uk" for "mail.example.co.uk"
+	WhoisServer string        `json:"whois_server"` // WHOIS server which answered the query
+	CreatedAt   time.Time     `json:"created_at"`   // creation date of the domain, zero when ParseFailed
+	AgeDays     int           `json:"age_days"`     // whole days since CreatedAt
+	ParseFailed bool          `json:"parse_failed"` // the creation date couldn't be found or parsed in the WHOIS response
+	Raw         string        `json:"-"`            // raw WHOIS response
+	Age         time.Duration `json:"-"`            // time since CreatedAt
+}
+
+// CheckDomainAge queries WHOIS for the creation date of the registered domain of domain.
+// The WHOIS server is the one set by WhoisServer, or the server of the top level domain
+// as told by IANA. When the response has no recognizable creation date, DomainAge.ParseFailed
+// is true rather than a guessed date. Errors are only returned when WHOIS can't be queried.
+func (v *Verifier) CheckDomainAge(domain string) (*DomainAge, error) {
+	v = v.snapshot()
+	return v.checkDomainAge(context.Background(), domain)
+}
+
+// checkDomainAge is CheckDomainAge bound to ctx
+func (v *Verifier) checkDomainAge(ctx context.Context, domain string) (*DomainAge, error) {
+	domain = cleanDomain(domain)
+	registered, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	server := v.whoisServer
+	if server == "" {
+		if server, err = v.whoisServerOf(ctx, registered); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := whoisQueryFunc(ctx, server, registered, v.connectTimeout, v.operationTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// thin registries only refer to the WHOIS server of the registrar
+	createdAt, ok := parseWhoisCreationDate(raw)
+	if !ok {
+		if referral := whoisField(raw, "registrar whois server"); referral != "" && referral != server {
+			if referralRaw, err := whoisQueryFunc(ctx, referral, registered, v.connectTimeout, v.operationTimeout); err == nil {
+				server, raw = referral, referralRaw
+				createdAt, ok = parseWhoisCreationDate(raw)
+			}
+		}
+	}
+
+	ret := &DomainAge{
+		Domain:      registered,
+		WhoisServer: server,
+		Raw:         raw,
+		ParseFailed: !ok,
+	}
+	if ok {
+		ret.CreatedAt = createdAt
+		ret.Age = time.Since(createdAt)
+		ret.AgeDays = int(ret.Age.Hours() / 24)
+	}
+	return ret, nil
+}
+
+// whoisServerOf returns the WHOIS server of the top level domain of domain as told by IANA
+func (v *Verifier) whoisServerOf(ctx context.Context, domain string) (string, error) {
+	tld := domain[strings.LastIndex(domain, ".")+1:]
+	if server, ok := whoisServers.Load(tld); ok {
+		return server.(string), nil
+	}
+	raw, err := whoisQueryFunc(ctx, whoisIANAServer, tld, v.connectTimeout, v.operationTimeout)
+	if err != nil {
+		return "", err
+	}
+	server := whoisField(raw, "whois")
+	if server == "" {
+		server = whoisField(raw, "refer")
+	}
+	if server == "" {
+		return "", errors.New("no WHOIS server for ." + tld)
+	}
+	whoisServers.Store(tld, server)
+	return server, nil
+}
+
+// queryWhois sends query to the WHOIS server and returns its response
+func queryWhois(ctx context.Context, server, query string, connectTimeout, readTimeout time.Duration) (string, error) {
+	if !strings.Contains(server, ":") {
+		server += whoisPort
+	}
+	dialer := net.Dialer{Timeout: connectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err = conn.SetDeadline(time.Now().Add(readTimeout)); err != nil {
+		return "", err
+	}
+	if _, err = conn.Write([]byte(query + "\r\n")); err != nil {
+		return "", err
+	}
+	raw, err := io.ReadAll(conn)
+	return string(raw), err
+}
+
+// whoisField returns the value of the first "key: value" line of raw with key, the key is case-insensitive
+func whoisField(raw, key string) string {
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		k, value, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.EqualFold(strings.TrimSpace(k), key) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// parseWhoisCreationDate finds the creation date in a WHOIS response,
+// false is returned when no creation date is found or it is in an unknown format
+func parseWhoisCreationDate(raw string) (time.Time, bool) {
+	for _, key := range whoisCreationKeys {
+		value := whoisField(raw, key)
+		if value == "" {
+			continue
+		}
+		// some registries append a comment after the date, e.g. "2001-01-01 (YYYY-MM-DD)"
+		if i := strings.Index(value, " ("); i > 0 {
+			value = value[:i]
+		}
+		for _, layout := range whoisDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
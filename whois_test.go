@@ -0,0 +1,94 @@
+package emailverifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubWhois answers whoisQueryFunc from responses keyed by "server query"
+func stubWhois(responses map[string]string) func() {
+	original := whoisQueryFunc
+	whoisQueryFunc = func(ctx context.Context, server, query string, connectTimeout, readTimeout time.Duration) (string, error) {
+		if raw, ok := responses[server+" "+query]; ok {
+			return raw, nil
+		}
+		return "", errors.New("connection refused")
+	}
+	return func() { whoisQueryFunc = original }
+}
+
+func TestParseWhoisCreationDate(t *testing.T) {
+	cases := map[string]time.Time{
+		"Domain Name: EXAMPLE.COM\r\n   Creation Date: 1995-08-14T04:00:00Z\r\n": time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC),
+		"domain:       example.de\ncreated:      2001-05-03\n":                   time.Date(2001, 5, 3, 0, 0, 0, 0, time.UTC),
+		"    Registered on: 26-Jun-1996\n":                                       time.Date(1996, 6, 26, 0, 0, 0, 0, time.UTC),
+		"Registration Time: 2003-03-17 12:20:05\n":                               time.Date(2003, 3, 17, 12, 20, 5, 0, time.UTC),
+		"created:      2010.04.02 (YYYY.MM.DD)\n":                                time.Date(2010, 4, 2, 0, 0, 0, 0, time.UTC),
+	}
+	for raw, expected := range cases {
+		createdAt, ok := parseWhoisCreationDate(raw)
+		assert.True(t, ok, raw)
+		assert.Equal(t, expected, createdAt, raw)
+	}
+
+	for _, raw := range []string{
+		"No match for domain \"EXAMPLE.INVALID\".\n",
+		"Creation Date: sometime in the nineties\n",
+		"Creation Date: 03/04/2005\n", // ambiguous day and month
+	} {
+		_, ok := parseWhoisCreationDate(raw)
+		assert.False(t, ok, raw)
+	}
+}
+
+func TestCheckDomainAge(t *testing.T) {
+	whoisServers.Delete("uk")
+	defer stubWhois(map[string]string{
+		"whois.iana.org uk":                   "domain:       UK\nwhois:        whois.nic.uk\n",
+		"whois.nic.uk example.co.uk":          "    Registered on: 26-Jun-1996\n",
+		"whois.example.net example.co.uk":     "Creation Date: 2020-01-01T00:00:00Z\n",
+		"whois.example.net unparseable.co.uk": "Creation Date: unknown\n",
+	})()
+
+	v := NewVerifier()
+	age, err := v.CheckDomainAge("mail.Example.co.uk")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.co.uk", age.Domain)
+	assert.Equal(t, "whois.nic.uk", age.WhoisServer)
+	assert.False(t, age.ParseFailed)
+	assert.Equal(t, time.Date(1996, 6, 26, 0, 0, 0, 0, time.UTC), age.CreatedAt)
+	assert.Greater(t, age.AgeDays, 365*25)
+
+	v.WhoisServer("whois.example.net")
+	age, err = v.CheckDomainAge("example.co.uk")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), age.CreatedAt)
+
+	age, err = v.CheckDomainAge("unparseable.co.uk")
+	assert.NoError(t, err)
+	assert.True(t, age.ParseFailed)
+	assert.True(t, age.CreatedAt.IsZero())
+}
+
+func TestCheckDomainAge_RegistrarReferral(t *testing.T) {
+	defer stubWhois(map[string]string{
+		"whois.registry.example example.com":  "Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: whois.registrar.example\n",
+		"whois.registrar.example example.com": "Creation Date: 1995-08-14T04:00:00Z\n",
+	})()
+
+	age, err := NewVerifier().WhoisServer("whois.registry.example").CheckDomainAge("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "whois.registrar.example", age.WhoisServer)
+	assert.False(t, age.ParseFailed)
+}
+
+func TestCheckDomainAge_QueryFailed(t *testing.T) {
+	defer stubWhois(nil)()
+
+	_, err := NewVerifier().WhoisServer("whois.example.net").CheckDomainAge("example.com")
+	assert.Error(t, err)
+}